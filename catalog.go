@@ -0,0 +1,293 @@
+package instill
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// CatalogIndex is the index.json of a skills catalog: a static file listing
+// every skill a catalog offers, and where to download each version.
+//
+//	{"skills": [{"name": "my-skill", "description": "...", "versions": [
+//	  {"version": "1.2.0", "url": "my-skill-1.2.0.zip", "digest": "sha256:..."}]}]}
+type CatalogIndex struct {
+	Skills []CatalogSkill `json:"skills"`
+}
+
+// CatalogSkill is one skill listed in a CatalogIndex.
+type CatalogSkill struct {
+	Name        string           `json:"name"`
+	Description string           `json:"description,omitempty"`
+	Versions    []CatalogRelease `json:"versions"`
+}
+
+// CatalogRelease is one downloadable version of a skill.
+type CatalogRelease struct {
+	Version string `json:"version"`
+	URL     string `json:"url"`    // .zip, .skill, .tar.gz or .tgz archive, absolute or relative to the index
+	Digest  string `json:"digest"` // "sha256:" and the hex SHA-256 of the archive
+}
+
+// Latest returns the highest version of the skill by semantic version
+// precedence. Versions that are not semantic versions rank below those that
+// are, in the order listed. It returns false if the skill has no versions.
+func (s CatalogSkill) Latest() (CatalogRelease, bool) {
+	if len(s.Versions) == 0 {
+		return CatalogRelease{}, false
+	}
+	best := s.Versions[0]
+	for _, r := range s.Versions[1:] {
+		if _, ok := parseVersion(best.Version); !ok {
+			if _, ok := parseVersion(r.Version); ok {
+				best = r
+			}
+			continue
+		}
+		if c, ok := compareVersions(r.Version, best.Version); ok && c > 0 {
+			best = r
+		}
+	}
+	return best, true
+}
+
+// Catalog is a client for a skills catalog served by any static HTTP server.
+// The index is revalidated with ETag and Last-Modified on every use, and
+// archives are cached by digest, so each version is downloaded once.
+type Catalog struct {
+	URL      string       // URL of the catalog's index.json
+	Client   *http.Client // defaults to http.DefaultClient
+	CacheDir string       // defaults to instill/catalog in os.UserCacheDir
+}
+
+// cacheMeta holds the validators of a cached index.
+type cacheMeta struct {
+	ETag         string `json:"etag,omitempty"`
+	LastModified string `json:"lastModified,omitempty"`
+}
+
+func (c *Catalog) client() *http.Client {
+	if c.Client != nil {
+		return c.Client
+	}
+	return http.DefaultClient
+}
+
+func (c *Catalog) cacheDir() (string, error) {
+	if c.CacheDir != "" {
+		return c.CacheDir, nil
+	}
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", fmt.Errorf("instill: catalog cache: %w", err)
+	}
+	return filepath.Join(dir, "instill", "catalog"), nil
+}
+
+// Index fetches the catalog's index. A cached copy is used when the server
+// reports it unchanged.
+func (c *Catalog) Index(ctx context.Context) (*CatalogIndex, error) {
+	dir, err := c.cacheDir()
+	if err != nil {
+		return nil, err
+	}
+	key := sha256.Sum256([]byte(c.URL))
+	dir = filepath.Join(dir, "index", hex.EncodeToString(key[:8]))
+	cached := filepath.Join(dir, "index.json")
+	metaPath := filepath.Join(dir, "meta.json")
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.URL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("instill: catalog: %w", err)
+	}
+	var meta cacheMeta
+	if data, err := os.ReadFile(metaPath); err == nil && json.Unmarshal(data, &meta) == nil {
+		if _, err := os.Stat(cached); err == nil {
+			if meta.ETag != "" {
+				req.Header.Set("If-None-Match", meta.ETag)
+			}
+			if meta.LastModified != "" {
+				req.Header.Set("If-Modified-Since", meta.LastModified)
+			}
+		}
+	}
+	resp, err := c.client().Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("instill: fetching catalog index: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var data []byte
+	switch resp.StatusCode {
+	case http.StatusNotModified:
+		if data, err = os.ReadFile(cached); err != nil {
+			return nil, fmt.Errorf("instill: reading cached catalog index: %w", err)
+		}
+	case http.StatusOK:
+		if data, err = readLimited(resp.Body); err != nil {
+			return nil, fmt.Errorf("instill: fetching catalog index: %w", err)
+		}
+		meta = cacheMeta{ETag: resp.Header.Get("ETag"), LastModified: resp.Header.Get("Last-Modified")}
+		if meta != (cacheMeta{}) {
+			metaData, _ := json.Marshal(meta)
+			// The cache is an optimization: failing to write it is not an error.
+			if writeCacheFile(cached, data) == nil {
+				_ = writeCacheFile(metaPath, metaData)
+			}
+		}
+	default:
+		return nil, fmt.Errorf("instill: fetching catalog index %s: %s", c.URL, resp.Status)
+	}
+	var idx CatalogIndex
+	if err := json.Unmarshal(data, &idx); err != nil {
+		return nil, fmt.Errorf("instill: parsing catalog index %s: %w", c.URL, err)
+	}
+	return &idx, nil
+}
+
+// Search returns the skills in the catalog whose name or description
+// contains query, ignoring case, in index order. An empty query matches
+// every skill.
+func (c *Catalog) Search(ctx context.Context, query string) ([]CatalogSkill, error) {
+	idx, err := c.Index(ctx)
+	if err != nil {
+		return nil, err
+	}
+	query = strings.ToLower(query)
+	var out []CatalogSkill
+	for _, s := range idx.Skills {
+		if strings.Contains(strings.ToLower(s.Name), query) || strings.Contains(strings.ToLower(s.Description), query) {
+			out = append(out, s)
+		}
+	}
+	return out, nil
+}
+
+// Fetch downloads a version of a skill, verifies the archive's digest and
+// returns its contents. An empty version selects the latest one; see
+// CatalogSkill.Latest. Verified archives are cached and reused.
+func (c *Catalog) Fetch(ctx context.Context, name, version string) (fs.FS, error) {
+	idx, err := c.Index(ctx)
+	if err != nil {
+		return nil, err
+	}
+	i := slices.IndexFunc(idx.Skills, func(s CatalogSkill) bool { return s.Name == name })
+	if i < 0 {
+		return nil, fmt.Errorf("instill: skill %q not found in catalog %s", name, c.URL)
+	}
+	rel, ok := idx.Skills[i].Latest()
+	if version != "" {
+		j := slices.IndexFunc(idx.Skills[i].Versions, func(r CatalogRelease) bool { return r.Version == version })
+		if ok = j >= 0; ok {
+			rel = idx.Skills[i].Versions[j]
+		}
+	}
+	if !ok {
+		return nil, fmt.Errorf("instill: skill %q has no version %q in catalog %s", name, version, c.URL)
+	}
+	sum, ok := strings.CutPrefix(strings.ToLower(rel.Digest), "sha256:")
+	if _, err := hex.DecodeString(sum); !ok || err != nil || len(sum) != sha256.Size*2 {
+		return nil, fmt.Errorf("instill: skill %q version %q has invalid digest %q", name, rel.Version, rel.Digest)
+	}
+	u, err := url.Parse(c.URL)
+	if err != nil {
+		return nil, fmt.Errorf("instill: catalog: %w", err)
+	}
+	if u, err = u.Parse(rel.URL); err != nil {
+		return nil, fmt.Errorf("instill: skill %q version %q: %w", name, rel.Version, err)
+	}
+
+	dir, err := c.cacheDir()
+	if err != nil {
+		return nil, err
+	}
+	cached := filepath.Join(dir, "archives", sum)
+	data, err := os.ReadFile(cached)
+	if err != nil || hashBytes(data) != "sha256:"+sum {
+		if data, err = c.download(ctx, u.String()); err != nil {
+			return nil, err
+		}
+		if got := hashBytes(data); got != "sha256:"+sum {
+			return nil, fmt.Errorf("instill: %s: digest %s does not match %s", u, got, rel.Digest)
+		}
+		_ = writeCacheFile(cached, data)
+	}
+	return fromArchive(u.Path, data)
+}
+
+func (c *Catalog) download(ctx context.Context, u string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, fmt.Errorf("instill: downloading %s: %w", u, err)
+	}
+	resp, err := c.client().Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("instill: downloading %s: %w", u, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("instill: downloading %s: %s", u, resp.Status)
+	}
+	data, err := readLimited(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("instill: downloading %s: %w", u, err)
+	}
+	return data, nil
+}
+
+// Install fetches a version of a skill (see Fetch) and installs it. Unless
+// opts.Skills is set, only the named skill is installed from the archive.
+func (c *Catalog) Install(ctx context.Context, name, version string, opts Options) ([]Result, error) {
+	fsys, err := c.Fetch(ctx, name, version)
+	if err != nil {
+		return nil, err
+	}
+	if len(opts.Skills) == 0 {
+		opts.Skills = []string{name}
+	}
+	return InstallContext(ctx, fsys, opts)
+}
+
+// readLimited reads r up to maxSourceSize bytes.
+func readLimited(r io.Reader) ([]byte, error) {
+	data, err := io.ReadAll(io.LimitReader(r, maxSourceSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > maxSourceSize {
+		return nil, fmt.Errorf("larger than %d bytes", maxSourceSize)
+	}
+	return data, nil
+}
+
+// writeCacheFile atomically replaces a cache file, so that concurrent
+// readers never see a partial write.
+func writeCacheFile(name string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(name), 0o755); err != nil {
+		return err
+	}
+	f, err := os.CreateTemp(filepath.Dir(name), ".tmp-*")
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(data)
+	err = errors.Join(err, f.Close())
+	if err == nil {
+		err = os.Rename(f.Name(), name)
+	}
+	if err != nil {
+		os.Remove(f.Name())
+	}
+	return err
+}
@@ -0,0 +1,160 @@
+package instill
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// catalogServer serves a catalog index and archives like a static file
+// server, counting requests and conditional responses.
+type catalogServer struct {
+	files       map[string][]byte
+	requests    atomic.Int32
+	notModified atomic.Int32
+}
+
+func (s *catalogServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.requests.Add(1)
+	data, ok := s.files[strings.TrimPrefix(r.URL.Path, "/")]
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	w.Header().Set("ETag", `"`+hashBytes(data)[7:23]+`"`)
+	rec := &statusRecorder{ResponseWriter: w}
+	http.ServeContent(rec, r, r.URL.Path, time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC), bytes.NewReader(data))
+	if rec.status == http.StatusNotModified {
+		s.notModified.Add(1)
+	}
+}
+
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(code int) {
+	r.status = code
+	r.ResponseWriter.WriteHeader(code)
+}
+
+func newCatalog(t *testing.T, archive []byte, digest string) (*Catalog, *catalogServer) {
+	t.Helper()
+	idx := CatalogIndex{Skills: []CatalogSkill{
+		{Name: "alpha", Description: "Alpha helper", Versions: []CatalogRelease{
+			{Version: "1.0.0", URL: "archives/alpha-1.0.0.tar.gz", Digest: digest},
+			{Version: "1.10.0", URL: "archives/alpha-1.10.0.tar.gz", Digest: digest},
+			{Version: "1.9.0", URL: "archives/alpha-1.9.0.tar.gz", Digest: digest},
+		}},
+		{Name: "beta", Description: "Does beta things", Versions: []CatalogRelease{
+			{Version: "0.1.0", URL: "archives/beta", Digest: digest},
+		}},
+	}}
+	index, _ := json.Marshal(idx)
+	srv := &catalogServer{files: map[string][]byte{
+		"skills/index.json":                   index,
+		"skills/archives/alpha-1.10.0.tar.gz": archive,
+		"skills/archives/alpha-1.0.0.tar.gz":  archive,
+		"skills/archives/beta":                archive,
+	}}
+	ts := httptest.NewServer(srv)
+	t.Cleanup(ts.Close)
+	return &Catalog{URL: ts.URL + "/skills/index.json", Client: ts.Client(), CacheDir: t.TempDir()}, srv
+}
+
+func TestCatalogSearch(t *testing.T) {
+	c, srv := newCatalog(t, nil, "")
+	ctx := context.Background()
+
+	got, err := c.Search(ctx, "BETA")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 1 || got[0].Name != "beta" {
+		t.Errorf("Search(BETA) = %v, want beta", got)
+	}
+	all, err := c.Search(ctx, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(all) != 2 {
+		t.Errorf("Search(\"\") returned %d skills, want 2", len(all))
+	}
+	if n := srv.notModified.Load(); n != 1 {
+		t.Errorf("second index request got %d 304 responses, want 1", n)
+	}
+}
+
+func TestCatalogLatest(t *testing.T) {
+	s := CatalogSkill{Versions: []CatalogRelease{{Version: "nightly"}, {Version: "1.9.0"}, {Version: "v1.10.0"}, {Version: "1.10.0-rc.1"}}}
+	if r, ok := s.Latest(); !ok || r.Version != "v1.10.0" {
+		t.Errorf("Latest() = %q, %v, want v1.10.0", r.Version, ok)
+	}
+	if _, ok := (CatalogSkill{}).Latest(); ok {
+		t.Error("Latest() of a skill without versions returned ok")
+	}
+}
+
+func TestCatalogInstall(t *testing.T) {
+	archive := tarGz(t, packFiles)
+	c, srv := newCatalog(t, archive, hashBytes(archive))
+	ctx := context.Background()
+	project := t.TempDir()
+
+	results, err := c.Install(ctx, "alpha", "", Options{Agents: []string{"claude-code"}, ProjectDir: project})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 1 || results[0].Skill != "alpha" {
+		t.Fatalf("Install results = %+v, want alpha only", results)
+	}
+	if _, err := os.Stat(filepath.Join(project, ".claude/skills/alpha/references/docs.md")); err != nil {
+		t.Error(err)
+	}
+	if _, err := os.Stat(filepath.Join(c.CacheDir, "archives", hashBytes(archive)[7:])); err != nil {
+		t.Errorf("archive not cached: %v", err)
+	}
+
+	// A cached archive is not downloaded again, even from another URL.
+	before := srv.requests.Load()
+	if _, err := c.Fetch(ctx, "beta", "0.1.0"); err != nil {
+		t.Fatal(err)
+	}
+	if n := srv.requests.Load() - before; n != 1 {
+		t.Errorf("Fetch of a cached archive made %d requests, want 1 (the index)", n)
+	}
+
+	if _, err := c.Fetch(ctx, "alpha", "2.0.0"); err == nil || !strings.Contains(err.Error(), "no version") {
+		t.Errorf("Fetch of a missing version: err = %v", err)
+	}
+	if _, err := c.Fetch(ctx, "gamma", ""); err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Errorf("Fetch of a missing skill: err = %v", err)
+	}
+}
+
+func TestCatalogDigestMismatch(t *testing.T) {
+	archive := tarGz(t, packFiles)
+	c, _ := newCatalog(t, archive, hashBytes([]byte("something else")))
+	_, err := c.Fetch(context.Background(), "alpha", "1.0.0")
+	if err == nil || !strings.Contains(err.Error(), "does not match") {
+		t.Fatalf("err = %v, want digest mismatch", err)
+	}
+	entries, _ := os.ReadDir(filepath.Join(c.CacheDir, "archives"))
+	if len(entries) != 0 {
+		t.Errorf("unverified archive was cached: %v", entries)
+	}
+
+	c, _ = newCatalog(t, archive, "md5:abc")
+	if _, err := c.Fetch(context.Background(), "alpha", ""); err == nil || !strings.Contains(err.Error(), "invalid digest") {
+		t.Errorf("err = %v, want invalid digest", err)
+	}
+}
@@ -0,0 +1,880 @@
+// Command instill installs, removes and inspects Agent Skills from a
+// directory, an archive (.zip, .skill, .tar.gz, .tgz), a git repository or
+// an HTTP catalog.
+//
+// Usage:
+//
+//	instill install [flags] <source>
+//	instill install --catalog <url> [flags] <skill>[@version]
+//	instill install --tar <file> --agent name [flags] <source>
+//	instill remove [flags] <skill>...
+//	instill sync [flags] <source>
+//	instill list [--json] <source>
+//	instill search --catalog <url> [--json] [query]
+//	instill installed [--agent name] [--project dir] [--json]
+//	instill validate [--json] <source>
+//	instill verify [--project dir] [--json]
+//	instill sign --key <file> <dir>
+//	instill detect [--project dir] [--global] [--json]
+//	instill runtime [--json]
+//	instill agents [--json]
+package main
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	iofs "io/fs"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/tiulpin/instill"
+)
+
+// Exit codes.
+const (
+	exitOK    = 0
+	exitError = 1 // the operation failed or found problems
+	exitUsage = 2 // invalid command line
+)
+
+const usage = `usage: instill <command> [flags] [args]
+
+Commands:
+  install    install skills from a directory, archive or git repository
+  remove     remove installed skills by name
+  sync       make a project's skills match its instill.json
+  list       list skills found in a directory or archive
+  search     search the skills of an HTTP catalog
+  installed  list skills installed for agents, in project and global scope
+  validate   check skills against the Agent Skills specification
+  verify     check installed skills against the project's instill.lock
+  sign       sign the skills in a directory with an ed25519 key
+  detect     list agents whose config directories exist
+  runtime    print the agent running this process, if any
+  agents     list all supported agents
+
+Run 'instill <command> -h' for command flags.
+`
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+func run(args []string, stdout, stderr io.Writer) int {
+	if len(args) == 0 {
+		fmt.Fprint(stderr, usage)
+		return exitUsage
+	}
+	cmds := map[string]func(context.Context, []string, io.Writer) error{
+		"install":   cmdInstall,
+		"remove":    cmdRemove,
+		"sync":      cmdSync,
+		"list":      cmdList,
+		"search":    cmdSearch,
+		"installed": cmdInstalled,
+		"validate":  cmdValidate,
+		"verify":    cmdVerify,
+		"sign":      cmdSign,
+		"detect":    cmdDetect,
+		"runtime":   cmdRuntime,
+		"agents":    cmdAgents,
+	}
+	cmd, ok := cmds[args[0]]
+	if !ok {
+		if args[0] == "help" || args[0] == "-h" || args[0] == "--help" {
+			fmt.Fprint(stdout, usage)
+			return exitOK
+		}
+		fmt.Fprintf(stderr, "instill: unknown command %q\n\n%s", args[0], usage)
+		return exitUsage
+	}
+	// Interrupting an install or removal rolls it back.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	err := cmd(ctx, args[1:], stdout)
+	var ue usageError
+	switch {
+	case err == nil:
+		return exitOK
+	case errors.Is(err, flag.ErrHelp):
+		return exitOK
+	case errors.As(err, &ue):
+		fmt.Fprintf(stderr, "instill %s: %v\n", args[0], err)
+		return exitUsage
+	case errors.Is(err, errProblems):
+		return exitError
+	default:
+		fmt.Fprintln(stderr, err)
+		return exitError
+	}
+}
+
+type usageError struct{ error }
+
+// errProblems signals failure after the problems were already reported.
+var errProblems = errors.New("problems found")
+
+// listFlag collects a repeatable, comma-separated flag.
+type listFlag []string
+
+func (l *listFlag) String() string { return strings.Join(*l, ",") }
+
+func (l *listFlag) Set(v string) error {
+	for s := range strings.SplitSeq(v, ",") {
+		if s = strings.TrimSpace(s); s != "" {
+			*l = append(*l, s)
+		}
+	}
+	return nil
+}
+
+// targetFlags mirror instill.Options.
+type targetFlags struct {
+	agents   listFlag
+	skills   listFlag
+	project  string
+	global   bool
+	json     bool
+	dryRun   bool
+	conflict string
+	lock     bool
+	frozen   bool
+	trusted  listFlag
+	symlink  bool
+	wait     time.Duration
+	keepOn   bool
+}
+
+func (t *targetFlags) register(fs *flag.FlagSet) {
+	fs.Var(&t.agents, "agent", "target agent (repeatable or comma-separated; default: detected agents)")
+	fs.StringVar(&t.project, "project", ".", "project root for project-level skills")
+	fs.BoolVar(&t.global, "global", false, "use global skill directories instead of project-level ones")
+	fs.BoolVar(&t.json, "json", false, "print JSON output")
+	fs.BoolVar(&t.dryRun, "dry-run", false, "print planned operations without changing anything")
+	fs.BoolVar(&t.lock, "lock", false, "record changes in the project's "+instill.LockFile)
+	fs.DurationVar(&t.wait, "lock-timeout", instill.DefaultLockTimeout, "how long to wait for other instill processes using the same directories")
+	fs.BoolVar(&t.keepOn, "keep-going", false, "apply each agent's skills directory separately and carry on past failures")
+}
+
+// registerInstall adds the flags that only apply to installs.
+func (t *targetFlags) registerInstall(fs *flag.FlagSet) {
+	fs.Var(&t.trusted, "trusted-key", "only install skills signed by this public key file (repeatable)")
+	fs.BoolVar(&t.symlink, "symlink", false, "install each skill once into "+instill.StoreDir+" and link agent directories to it")
+}
+
+// lockTimeout returns --lock-timeout as an Options.LockTimeout, where zero
+// means not waiting at all rather than the default.
+func (t *targetFlags) lockTimeout() time.Duration {
+	if t.wait == 0 {
+		return -1
+	}
+	return t.wait
+}
+
+func (t *targetFlags) options() (instill.Options, error) {
+	opts := instill.Options{Agents: t.agents, Skills: t.skills, ProjectDir: t.project, Global: t.global, Lock: t.lock, Frozen: t.frozen, Symlink: t.symlink, LockTimeout: t.lockTimeout(), ContinueOnError: t.keepOn}
+	var err error
+	if opts.Conflict, err = parseConflict(t.conflict); err != nil {
+		return opts, err
+	}
+	if opts.TrustedKeys, err = readPublicKeys(t.trusted); err != nil {
+		return opts, err
+	}
+	if len(opts.Agents) == 0 {
+		detected, err := instill.Detect(opts.ProjectDir, opts.Global)
+		if err != nil {
+			return opts, err
+		}
+		for _, a := range detected {
+			opts.Agents = append(opts.Agents, a.Name)
+		}
+		if len(opts.Agents) == 0 {
+			return opts, usageError{errors.New("no agents detected; pass --agent")}
+		}
+	}
+	return opts, nil
+}
+
+// sourceFlags select where skills are read from.
+type sourceFlags struct {
+	ref    string
+	subdir string
+}
+
+func (sf *sourceFlags) register(fs *flag.FlagSet) {
+	fs.StringVar(&sf.ref, "ref", "", "read skills from this ref of the git repository at <source> instead of its working tree")
+	fs.StringVar(&sf.subdir, "subdir", "", "with --ref, the directory of the repository that holds the skills")
+}
+
+// open returns the skills at src: a directory, a .zip, .skill, .tar.gz or
+// .tgz archive, or a git repository when --ref is set.
+func (sf *sourceFlags) open(src string) (iofs.FS, error) {
+	if sf.ref != "" {
+		return instill.FromGit(src, sf.ref, sf.subdir)
+	}
+	if sf.subdir != "" {
+		return nil, usageError{errors.New("--subdir requires --ref")}
+	}
+	fi, err := os.Stat(src)
+	if err != nil {
+		return nil, err
+	}
+	if fi.IsDir() {
+		return os.DirFS(src), nil
+	}
+	return instill.OpenArchive(src)
+}
+
+// tarFlags select writing an install to a tar archive instead of the disk.
+type tarFlags struct {
+	path     string
+	home     string
+	owner    string
+	fileMode string
+	dirMode  string
+}
+
+func (tf *tarFlags) register(fs *flag.FlagSet) {
+	fs.StringVar(&tf.path, "tar", "", "write the install to this tar archive (- for stdout) instead of the disk")
+	fs.StringVar(&tf.home, "tar-home", "", "with --tar, the home directory in the image for global skills")
+	fs.StringVar(&tf.owner, "tar-owner", "0:0", "with --tar, the uid[:gid] owning every entry")
+	fs.StringVar(&tf.fileMode, "tar-file-mode", "", "with --tar, the octal permissions of files (default 644)")
+	fs.StringVar(&tf.dirMode, "tar-dir-mode", "", "with --tar, the octal permissions of directories (default 755)")
+}
+
+func (tf *tarFlags) options() (instill.TarOptions, error) {
+	opts := instill.TarOptions{Home: tf.home}
+	uid, gid, hasGid := strings.Cut(tf.owner, ":")
+	var err error
+	if opts.Uid, err = strconv.Atoi(uid); err != nil || opts.Uid < 0 {
+		return opts, usageError{fmt.Errorf("invalid --tar-owner %q (want uid[:gid])", tf.owner)}
+	}
+	opts.Gid = opts.Uid
+	if hasGid {
+		if opts.Gid, err = strconv.Atoi(gid); err != nil || opts.Gid < 0 {
+			return opts, usageError{fmt.Errorf("invalid --tar-owner %q (want uid[:gid])", tf.owner)}
+		}
+	}
+	for _, m := range []struct {
+		flag, value string
+		mode        *iofs.FileMode
+	}{{"--tar-file-mode", tf.fileMode, &opts.FileMode}, {"--tar-dir-mode", tf.dirMode, &opts.DirMode}} {
+		if m.value == "" {
+			continue
+		}
+		v, err := strconv.ParseUint(m.value, 8, 32)
+		if err != nil || v > 0o777 {
+			return opts, usageError{fmt.Errorf("invalid %s %q (want octal permissions such as 644)", m.flag, m.value)}
+		}
+		*m.mode = iofs.FileMode(v)
+	}
+	return opts, nil
+}
+
+// installTar installs src into the archive at tf.path. Results are printed
+// unless the archive itself goes to w.
+func installTar(ctx context.Context, w io.Writer, src iofs.FS, opts instill.Options, tf tarFlags, t targetFlags) error {
+	tarOpts, err := tf.options()
+	if err != nil {
+		return err
+	}
+	if tf.path == "-" {
+		_, err := instill.InstallTarContext(ctx, w, src, opts, tarOpts)
+		return err
+	}
+	f, err := os.Create(tf.path)
+	if err != nil {
+		return err
+	}
+	results, err := instill.InstallTarContext(ctx, f, src, opts, tarOpts)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil && len(results) == 0 {
+		os.Remove(tf.path)
+	}
+	return printResults(w, results, err, t, "installed")
+}
+
+func parseConflict(s string) (instill.ConflictPolicy, error) {
+	switch s {
+	case "", "overwrite":
+		return instill.ConflictOverwrite, nil
+	case "keep":
+		return instill.ConflictKeep, nil
+	case "backup":
+		return instill.ConflictBackup, nil
+	case "fail":
+		return instill.ConflictFail, nil
+	}
+	return 0, usageError{fmt.Errorf("invalid --conflict %q (want overwrite, keep, backup or fail)", s)}
+}
+
+func newFlagSet(name, args string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "usage: instill %s [flags] %s\n\nFlags:\n", name, args)
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+func parse(fs *flag.FlagSet, args []string, nargs int) error {
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return err
+		}
+		return usageError{err}
+	}
+	if nargs >= 0 && fs.NArg() != nargs {
+		return usageError{fmt.Errorf("expected %d argument(s), got %d", nargs, fs.NArg())}
+	}
+	if nargs < 0 && fs.NArg() == 0 {
+		return usageError{errors.New("expected at least one argument")}
+	}
+	return nil
+}
+
+func cmdInstall(ctx context.Context, args []string, w io.Writer) error {
+	var t targetFlags
+	var catalog string
+	fs := newFlagSet("install", "<source>")
+	t.register(fs)
+	fs.Var(&t.skills, "skill", "only install these skills (repeatable or comma-separated)")
+	fs.StringVar(&t.conflict, "conflict", "overwrite", "what to do with locally modified files: overwrite, keep, backup or fail")
+	fs.BoolVar(&t.frozen, "frozen", false, "refuse to install skills whose content does not match "+instill.LockFile)
+	fs.StringVar(&catalog, "catalog", "", "install <skill>[@version] from the catalog whose index.json is at this URL")
+	t.registerInstall(fs)
+	var sf sourceFlags
+	sf.register(fs)
+	var tf tarFlags
+	tf.register(fs)
+	if err := parse(fs, args, 1); err != nil {
+		return err
+	}
+	if tf.path != "" && (len(t.agents) == 0 || t.dryRun) {
+		return usageError{errors.New("--tar requires --agent and cannot be combined with --dry-run")}
+	}
+	name, version, _ := strings.Cut(fs.Arg(0), "@")
+	if catalog != "" && len(t.skills) == 0 {
+		t.skills = listFlag{name}
+	}
+	opts, err := t.options()
+	if err != nil {
+		return err
+	}
+	var src iofs.FS
+	if catalog != "" {
+		c := &instill.Catalog{URL: catalog}
+		src, err = c.Fetch(ctx, name, version)
+	} else {
+		src, err = sf.open(fs.Arg(0))
+	}
+	if err != nil {
+		return err
+	}
+	if tf.path != "" {
+		return installTar(ctx, w, src, opts, tf, t)
+	}
+	if !t.dryRun {
+		// Install locks the target directories before planning, so that
+		// nothing changes between planning and applying.
+		results, err := instill.InstallContext(ctx, src, opts)
+		return printResults(w, results, err, t, "installed")
+	}
+	plan, err := instill.PlanInstall(src, opts)
+	if err != nil {
+		return err
+	}
+	return applyAndPrint(ctx, w, plan, t, "installed")
+}
+
+func cmdRemove(ctx context.Context, args []string, w io.Writer) error {
+	var t targetFlags
+	fs := newFlagSet("remove", "<skill>...")
+	t.register(fs)
+	if err := parse(fs, args, -1); err != nil {
+		return err
+	}
+	opts, err := t.options()
+	if err != nil {
+		return err
+	}
+	if !t.dryRun {
+		var results []instill.Result
+		var errs []error
+		for _, name := range fs.Args() {
+			rs, err := instill.RemoveContext(ctx, name, opts)
+			results = append(results, rs...)
+			if err != nil && !t.keepOn {
+				if perr := printResults(w, results, nil, t, "removed"); perr != nil {
+					return perr
+				}
+				return err
+			}
+			errs = append(errs, err)
+		}
+		return printResults(w, results, errors.Join(errs...), t, "removed")
+	}
+	combined := &instill.Plan{}
+	for _, name := range fs.Args() {
+		plan, err := instill.PlanRemove(name, opts)
+		if err != nil {
+			return err
+		}
+		if err := combined.Append(plan); err != nil {
+			return err
+		}
+	}
+	return applyAndPrint(ctx, w, combined, t, "removed")
+}
+
+func cmdSync(ctx context.Context, args []string, w io.Writer) error {
+	var t targetFlags
+	fs := newFlagSet("sync", "<source>")
+	fs.StringVar(&t.project, "project", ".", "project root containing "+instill.ConfigFile)
+	fs.BoolVar(&t.global, "global", false, "sync global skill directories instead of project-level ones")
+	fs.BoolVar(&t.json, "json", false, "print JSON output")
+	fs.BoolVar(&t.dryRun, "dry-run", false, "print planned changes without changing anything")
+	fs.StringVar(&t.conflict, "conflict", "overwrite", "what to do with locally modified files: overwrite, keep, backup or fail")
+	fs.BoolVar(&t.lock, "lock", false, "record changes in the project's "+instill.LockFile)
+	fs.BoolVar(&t.frozen, "frozen", false, "refuse to install skills whose content does not match "+instill.LockFile)
+	fs.DurationVar(&t.wait, "lock-timeout", instill.DefaultLockTimeout, "how long to wait for other instill processes using the same directories")
+	t.registerInstall(fs)
+	var sf sourceFlags
+	sf.register(fs)
+	if err := parse(fs, args, 1); err != nil {
+		return err
+	}
+	conflict, err := parseConflict(t.conflict)
+	if err != nil {
+		return err
+	}
+	trusted, err := readPublicKeys(t.trusted)
+	if err != nil {
+		return err
+	}
+	src, err := sf.open(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	opts := instill.Options{ProjectDir: t.project, Global: t.global, Conflict: conflict, Lock: t.lock, Frozen: t.frozen, TrustedKeys: trusted, Symlink: t.symlink, LockTimeout: t.lockTimeout()}
+	sp, err := instill.PlanSync(src, opts)
+	if err != nil {
+		return err
+	}
+	if !t.dryRun {
+		if _, err := sp.ApplyContext(ctx); err != nil {
+			return err
+		}
+	}
+	if t.json {
+		return writeJSON(w, sp.Changes)
+	}
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	for _, c := range sp.Changes {
+		if c.Action == instill.SyncKeep {
+			continue
+		}
+		versions := c.To
+		if c.From != "" && c.From != c.To {
+			versions = strings.TrimSuffix(c.From+" -> "+c.To, " -> ")
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", c.Action, c.Skill, c.Agent, versions)
+	}
+	return tw.Flush()
+}
+
+// opJSON is an Op without its file content.
+type opJSON struct {
+	Kind  string `json:"kind"`
+	Path  string `json:"path"`
+	Skill string `json:"skill"`
+}
+
+func applyAndPrint(ctx context.Context, w io.Writer, plan *instill.Plan, t targetFlags, verb string) error {
+	if t.dryRun {
+		if t.json {
+			ops := make([]opJSON, len(plan.Ops))
+			for i, op := range plan.Ops {
+				ops[i] = opJSON{op.Kind.String(), op.Path, op.Skill}
+			}
+			return writeJSON(w, ops)
+		}
+		for _, op := range plan.Ops {
+			fmt.Fprintf(w, "%-9s %s\n", op.Kind, op.Path)
+		}
+		return nil
+	}
+	results, err := plan.ApplyContext(ctx)
+	if err != nil {
+		return err
+	}
+	return printResults(w, results, nil, t, verb)
+}
+
+// resultJSON is a Result with its error as text.
+type resultJSON struct {
+	instill.Result
+	Err string `json:",omitempty"`
+}
+
+// printResults prints results, including those that failed under
+// --keep-going, and reports err as errProblems once they are printed.
+func printResults(w io.Writer, results []instill.Result, err error, t targetFlags, verb string) error {
+	if err != nil && len(results) == 0 {
+		return err
+	}
+	if err != nil {
+		err = errProblems
+	}
+	if t.json {
+		out := make([]resultJSON, len(results))
+		for i, r := range results {
+			out[i].Result = r
+			if r.Err != nil {
+				out[i].Err = r.Err.Error()
+			}
+		}
+		if werr := writeJSON(w, out); werr != nil {
+			return werr
+		}
+		return err
+	}
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	for _, r := range results {
+		verb, note := verb, ""
+		switch {
+		case r.Err != nil:
+			verb, note = "failed", r.Err.Error()
+		case verb == "removed" && !r.Existed:
+			note = "not installed"
+		case verb == "removed" && len(r.Owners) > 0:
+			note = "kept for " + strings.Join(r.Owners, ", ")
+		case verb == "installed" && r.PriorVersion != "":
+			note = "was " + r.PriorVersion
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", verb, r.Skill, r.Agent, r.Path, note)
+		for _, c := range r.Conflicts {
+			fmt.Fprintf(tw, "  %s\t%s\t\t\t\n", c.Outcome, c.File)
+		}
+	}
+	if ferr := tw.Flush(); ferr != nil {
+		return ferr
+	}
+	return err
+}
+
+func cmdList(ctx context.Context, args []string, w io.Writer) error {
+	var asJSON bool
+	fs := newFlagSet("list", "<source>")
+	fs.BoolVar(&asJSON, "json", false, "print JSON output")
+	if err := parse(fs, args, 1); err != nil {
+		return err
+	}
+	var sf sourceFlags
+	src, err := sf.open(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	skills := instill.ListSkills(src)
+	if asJSON {
+		return writeJSON(w, skills)
+	}
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	for _, s := range skills {
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", s.Name, s.Version, s.Description)
+	}
+	return tw.Flush()
+}
+
+func cmdSearch(ctx context.Context, args []string, w io.Writer) error {
+	var (
+		catalog string
+		asJSON  bool
+	)
+	fs := newFlagSet("search", "[query]")
+	fs.StringVar(&catalog, "catalog", "", "URL of the catalog's index.json (required)")
+	fs.BoolVar(&asJSON, "json", false, "print JSON output")
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return err
+		}
+		return usageError{err}
+	}
+	if catalog == "" {
+		return usageError{errors.New("--catalog is required")}
+	}
+	if fs.NArg() > 1 {
+		return usageError{fmt.Errorf("expected at most 1 argument, got %d", fs.NArg())}
+	}
+	c := &instill.Catalog{URL: catalog}
+	skills, err := c.Search(ctx, fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	if asJSON {
+		return writeJSON(w, skills)
+	}
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	for _, s := range skills {
+		latest, _ := s.Latest()
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", s.Name, latest.Version, s.Description)
+	}
+	return tw.Flush()
+}
+
+func cmdInstalled(ctx context.Context, args []string, w io.Writer) error {
+	var (
+		agents  listFlag
+		project string
+		asJSON  bool
+	)
+	fs := newFlagSet("installed", "")
+	fs.Var(&agents, "agent", "only list skills of these agents (repeatable or comma-separated; default: all agents)")
+	fs.StringVar(&project, "project", ".", "project root for project-level skills")
+	fs.BoolVar(&asJSON, "json", false, "print JSON output")
+	if err := parse(fs, args, 0); err != nil {
+		return err
+	}
+	skills, err := instill.ListInstalled(instill.Options{Agents: agents, ProjectDir: project})
+	if err != nil {
+		return err
+	}
+	if asJSON {
+		return writeJSON(w, skills)
+	}
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	for _, s := range skills {
+		managed := "unmanaged"
+		if s.Managed {
+			managed = "managed"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\n", s.Name, s.Meta.Version, s.Scope, strings.Join(s.Agents, ","), managed, s.Path)
+	}
+	return tw.Flush()
+}
+
+func cmdValidate(ctx context.Context, args []string, w io.Writer) error {
+	var asJSON bool
+	fs := newFlagSet("validate", "<source>")
+	fs.BoolVar(&asJSON, "json", false, "print JSON output")
+	if err := parse(fs, args, 1); err != nil {
+		return err
+	}
+	var sf sourceFlags
+	src, err := sf.open(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	diags, err := instill.Validate(src)
+	if err != nil {
+		return err
+	}
+	if asJSON {
+		if err := writeJSON(w, diags); err != nil {
+			return err
+		}
+	} else {
+		for _, d := range diags {
+			fmt.Fprintln(w, d)
+		}
+	}
+	for _, d := range diags {
+		if d.Severity == instill.SeverityError {
+			return errProblems
+		}
+	}
+	return nil
+}
+
+func cmdVerify(ctx context.Context, args []string, w io.Writer) error {
+	var (
+		project string
+		asJSON  bool
+	)
+	fs := newFlagSet("verify", "")
+	fs.StringVar(&project, "project", ".", "project root containing "+instill.LockFile)
+	fs.BoolVar(&asJSON, "json", false, "print JSON output")
+	if err := parse(fs, args, 0); err != nil {
+		return err
+	}
+	drift, err := instill.Verify(instill.Options{ProjectDir: project})
+	if err != nil {
+		return err
+	}
+	if asJSON {
+		if err := writeJSON(w, drift); err != nil {
+			return err
+		}
+	} else {
+		for _, d := range drift {
+			fmt.Fprintf(w, "%s: %s (%s for %s)\n", d.Path, d.Kind, d.Skill, d.Agent)
+		}
+	}
+	if len(drift) > 0 {
+		return errProblems
+	}
+	return nil
+}
+
+func cmdSign(ctx context.Context, args []string, w io.Writer) error {
+	var keyFile string
+	fs := newFlagSet("sign", "<dir>")
+	fs.StringVar(&keyFile, "key", "", "PKCS #8 PEM ed25519 private key, e.g. from 'openssl genpkey -algorithm ed25519' (required)")
+	if err := parse(fs, args, 1); err != nil {
+		return err
+	}
+	if keyFile == "" {
+		return usageError{errors.New("--key is required")}
+	}
+	key, err := readPrivateKey(keyFile)
+	if err != nil {
+		return err
+	}
+	names, err := instill.SignSkills(fs.Arg(0), key)
+	if err != nil {
+		return err
+	}
+	signer := instill.EncodePublicKey(key.Public().(ed25519.PublicKey))
+	for _, name := range names {
+		fmt.Fprintf(w, "signed %s with %s\n", name, signer)
+	}
+	return nil
+}
+
+func readPrivateKey(name string) (ed25519.PrivateKey, error) {
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("%s: not a PEM file", name)
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	ed, ok := key.(ed25519.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("%s: not an ed25519 key", name)
+	}
+	return ed, nil
+}
+
+// readPublicKeys reads public key files, each holding either a key printed
+// by 'instill sign' (ed25519:<base64>) or a PEM public key.
+func readPublicKeys(names []string) ([]ed25519.PublicKey, error) {
+	var keys []ed25519.PublicKey
+	for _, name := range names {
+		data, err := os.ReadFile(name)
+		if err != nil {
+			return nil, err
+		}
+		block, _ := pem.Decode(data)
+		if block == nil {
+			key, err := instill.ParsePublicKey(string(data))
+			if err != nil {
+				return nil, err
+			}
+			keys = append(keys, key)
+			continue
+		}
+		key, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		ed, ok := key.(ed25519.PublicKey)
+		if !ok {
+			return nil, fmt.Errorf("%s: not an ed25519 key", name)
+		}
+		keys = append(keys, ed)
+	}
+	return keys, nil
+}
+
+func cmdDetect(ctx context.Context, args []string, w io.Writer) error {
+	var (
+		project string
+		global  bool
+		asJSON  bool
+	)
+	fs := newFlagSet("detect", "")
+	fs.StringVar(&project, "project", ".", "project root to look for agent config directories in")
+	fs.BoolVar(&global, "global", false, "look for global config directories instead")
+	fs.BoolVar(&asJSON, "json", false, "print JSON output")
+	if err := parse(fs, args, 0); err != nil {
+		return err
+	}
+	agents, err := instill.DetectContext(ctx, project, global)
+	if err != nil {
+		return err
+	}
+	if asJSON {
+		return writeJSON(w, agents)
+	}
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	for _, a := range agents {
+		dir := a.ProjectDir
+		if global {
+			dir = a.GlobalDir
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", a.Name, a.DisplayName, dir)
+	}
+	return tw.Flush()
+}
+
+func cmdRuntime(ctx context.Context, args []string, w io.Writer) error {
+	var asJSON bool
+	fs := newFlagSet("runtime", "")
+	fs.BoolVar(&asJSON, "json", false, "print JSON output")
+	if err := parse(fs, args, 0); err != nil {
+		return err
+	}
+	a := instill.DetectRuntime()
+	if asJSON {
+		return writeJSON(w, a)
+	}
+	if a == nil {
+		fmt.Fprintln(w, "no agent detected")
+		return nil
+	}
+	fmt.Fprintf(w, "%s (%s) via %s\n", a.DisplayName, a.Name, a.EnvVar)
+	return nil
+}
+
+func cmdAgents(ctx context.Context, args []string, w io.Writer) error {
+	var asJSON bool
+	fs := newFlagSet("agents", "")
+	fs.BoolVar(&asJSON, "json", false, "print JSON output")
+	if err := parse(fs, args, 0); err != nil {
+		return err
+	}
+	agents := instill.Agents()
+	if asJSON {
+		return writeJSON(w, agents)
+	}
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	for _, a := range agents {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", a.Name, a.DisplayName, a.SkillsDir.Raw, a.Capabilities)
+	}
+	return tw.Flush()
+}
+
+func writeJSON(w io.Writer, v any) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
@@ -0,0 +1,360 @@
+package main
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/tiulpin/instill"
+)
+
+func writeSkill(t *testing.T, dir, name, frontmatter string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Join(dir, name), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, name, "SKILL.md"), []byte("---\n"+frontmatter+"---\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func runCmd(t *testing.T, args ...string) (int, string, string) {
+	t.Helper()
+	var stdout, stderr bytes.Buffer
+	code := run(args, &stdout, &stderr)
+	return code, stdout.String(), stderr.String()
+}
+
+func TestInstallAndRemove(t *testing.T) {
+	src, project := t.TempDir(), t.TempDir()
+	writeSkill(t, src, "x", "name: x\ndescription: d\nversion: 1.0\n")
+
+	code, out, errOut := runCmd(t, "install", "--agent", "claude-code,cursor", "--project", project, "--dry-run", src)
+	if code != exitOK {
+		t.Fatalf("dry run exit %d: %s", code, errOut)
+	}
+	if !strings.Contains(out, "write") || !strings.Contains(out, filepath.Join(".claude", "skills", "x", "SKILL.md")) {
+		t.Errorf("unexpected dry-run output:\n%s", out)
+	}
+	if _, err := os.Stat(filepath.Join(project, ".claude")); !os.IsNotExist(err) {
+		t.Fatal("dry run must not write")
+	}
+
+	code, out, errOut = runCmd(t, "install", "--agent", "claude-code", "--agent", "cursor", "--project", project, "--json", src)
+	if code != exitOK {
+		t.Fatalf("install exit %d: %s", code, errOut)
+	}
+	var results []struct{ Agent, Skill string }
+	if err := json.Unmarshal([]byte(out), &results); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, out)
+	}
+	if len(results) != 2 {
+		t.Errorf("expected 2 results, got %+v", results)
+	}
+
+	code, out, _ = runCmd(t, "remove", "--agent", "cursor", "--project", project, "x")
+	if code != exitOK || !strings.Contains(out, "removed") {
+		t.Fatalf("remove exit %d:\n%s", code, out)
+	}
+	if _, err := os.Stat(filepath.Join(project, ".agents/skills/x")); !os.IsNotExist(err) {
+		t.Error("cursor copy should be removed")
+	}
+	if _, err := os.Stat(filepath.Join(project, ".claude/skills/x")); err != nil {
+		t.Error("claude-code copy should remain")
+	}
+
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("CLAUDE_CONFIG_DIR", "")
+	code, out, _ = runCmd(t, "installed", "--agent", "claude-code,cursor", "--project", project)
+	if code != exitOK || strings.Count(out, "\n") != 1 || !strings.Contains(out, "claude-code") || !strings.Contains(out, "managed") {
+		t.Errorf("installed exit %d:\n%s", code, out)
+	}
+}
+
+func TestInstallFailureExitCode(t *testing.T) {
+	src := t.TempDir()
+	writeSkill(t, src, "x", "name: x\n")
+	code, _, errOut := runCmd(t, "install", "--agent", "nope", "--project", t.TempDir(), src)
+	if code != exitError || !strings.Contains(errOut, "unknown agent") {
+		t.Errorf("exit %d, stderr %q", code, errOut)
+	}
+}
+
+func TestUsageErrors(t *testing.T) {
+	for _, args := range [][]string{
+		nil,
+		{"bogus"},
+		{"install"},
+		{"install", "--conflict", "maybe", "--agent", "cursor", "dir"},
+		{"remove", "--agent", "cursor"},
+		{"install", "--tar", "-", "dir"},
+		{"detect", "extra"},
+	} {
+		if code, _, _ := runCmd(t, args...); code != exitUsage {
+			t.Errorf("%q: exit %d, want %d", args, code, exitUsage)
+		}
+	}
+}
+
+func TestListAndValidate(t *testing.T) {
+	src := t.TempDir()
+	writeSkill(t, src, "good", "name: good\ndescription: fine\nversion: 2.0\n")
+
+	code, out, _ := runCmd(t, "list", src)
+	if code != exitOK || !strings.Contains(out, "good") || !strings.Contains(out, "2.0") {
+		t.Errorf("list exit %d:\n%s", code, out)
+	}
+	if code, out, _ := runCmd(t, "validate", src); code != exitOK || out != "" {
+		t.Errorf("validate exit %d:\n%s", code, out)
+	}
+
+	writeSkill(t, src, "bad", "name: Bad Name\n")
+	code, out, _ = runCmd(t, "validate", "--json", src)
+	if code != exitError {
+		t.Errorf("validate exit %d, want %d", code, exitError)
+	}
+	var diags []struct{ Rule, Severity string }
+	if err := json.Unmarshal([]byte(out), &diags); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, out)
+	}
+	if len(diags) == 0 || diags[0].Severity != "error" {
+		t.Errorf("unexpected diagnostics: %+v", diags)
+	}
+}
+
+func TestRuntime(t *testing.T) {
+	t.Setenv("AI_AGENT", "claude-code")
+	code, out, _ := runCmd(t, "runtime")
+	if code != exitOK || !strings.Contains(out, "Claude Code") {
+		t.Errorf("runtime exit %d:\n%s", code, out)
+	}
+}
+
+func TestAgents(t *testing.T) {
+	code, out, _ := runCmd(t, "agents")
+	if code != exitOK || !strings.Contains(out, "claude-code") || !strings.Contains(out, "skills,commands,subagents,hooks,mcp") {
+		t.Errorf("agents exit %d:\n%s", code, out)
+	}
+}
+
+func TestSync(t *testing.T) {
+	src, project := t.TempDir(), t.TempDir()
+	writeSkill(t, src, "x", "name: x\nversion: 1.0.0\n")
+	if err := os.WriteFile(filepath.Join(project, "instill.json"), []byte(`{"agents": ["claude-code"]}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	code, out, errOut := runCmd(t, "sync", "--project", project, "--dry-run", src)
+	if code != exitOK || !strings.Contains(out, "install  x  claude-code  1.0.0") {
+		t.Fatalf("dry run exit %d: %s%s", code, out, errOut)
+	}
+	if _, err := os.Stat(filepath.Join(project, ".claude")); !os.IsNotExist(err) {
+		t.Fatal("dry run must not write")
+	}
+	if code, _, errOut = runCmd(t, "sync", "--project", project, src); code != exitOK {
+		t.Fatalf("sync exit %d: %s", code, errOut)
+	}
+	if _, err := os.Stat(filepath.Join(project, ".claude/skills/x/SKILL.md")); err != nil {
+		t.Error(err)
+	}
+	if code, out, _ = runCmd(t, "sync", "--project", project, src); code != exitOK || out != "" {
+		t.Errorf("second sync exit %d:\n%s", code, out)
+	}
+}
+
+func TestLockAndVerify(t *testing.T) {
+	src, project := t.TempDir(), t.TempDir()
+	writeSkill(t, src, "x", "name: x\n")
+	if code, _, errOut := runCmd(t, "install", "--agent", "claude-code", "--project", project, "--lock", src); code != exitOK {
+		t.Fatalf("install exit %d: %s", code, errOut)
+	}
+	if code, out, _ := runCmd(t, "verify", "--project", project); code != exitOK || out != "" {
+		t.Errorf("verify exit %d:\n%s", code, out)
+	}
+	if err := os.WriteFile(filepath.Join(project, ".claude/skills/x/SKILL.md"), []byte("edited"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if code, out, _ := runCmd(t, "verify", "--project", project); code != exitError || !strings.Contains(out, "SKILL.md: modified (x for claude-code)") {
+		t.Errorf("verify after edit exit %d:\n%s", code, out)
+	}
+
+	writeSkill(t, src, "x", "name: x\nversion: 2.0.0\n")
+	if code, _, errOut := runCmd(t, "install", "--agent", "claude-code", "--project", project, "--frozen", src); code != exitError || !strings.Contains(errOut, "does not match") {
+		t.Errorf("frozen install exit %d: %s", code, errOut)
+	}
+}
+
+func TestInstallFromArchive(t *testing.T) {
+	dir, project := t.TempDir(), t.TempDir()
+	archive := filepath.Join(dir, "x.skill")
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	w, _ := zw.Create("x/SKILL.md")
+	w.Write([]byte("---\nname: x\n---\n"))
+	zw.Close()
+	if err := os.WriteFile(archive, buf.Bytes(), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if code, _, errOut := runCmd(t, "install", "--agent", "claude-code", "--project", project, archive); code != exitOK {
+		t.Fatalf("install exit %d: %s", code, errOut)
+	}
+	if _, err := os.Stat(filepath.Join(project, ".claude/skills/x/SKILL.md")); err != nil {
+		t.Error(err)
+	}
+	if code, _, errOut := runCmd(t, "install", "--agent", "claude-code", "--project", project, "--subdir", "x", archive); code != exitUsage || !strings.Contains(errOut, "requires --ref") {
+		t.Errorf("--subdir without --ref: exit %d: %s", code, errOut)
+	}
+}
+
+func TestCatalog(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	t.Setenv("HOME", t.TempDir())
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	w, _ := zw.Create("x/SKILL.md")
+	w.Write([]byte("---\nname: x\nversion: 1.1.0\n---\n"))
+	zw.Close()
+	sum := sha256.Sum256(buf.Bytes())
+	index := `{"skills": [{"name": "x", "description": "Does x", "versions": [
+		{"version": "1.0.0", "url": "x-1.0.0.zip", "digest": "sha256:0000000000000000000000000000000000000000000000000000000000000000"},
+		{"version": "1.1.0", "url": "x-1.1.0.zip", "digest": "sha256:` + hex.EncodeToString(sum[:]) + `"}]}]}`
+	mux := http.NewServeMux()
+	mux.HandleFunc("/index.json", func(w http.ResponseWriter, r *http.Request) { w.Write([]byte(index)) })
+	mux.HandleFunc("/x-1.1.0.zip", func(w http.ResponseWriter, r *http.Request) { w.Write(buf.Bytes()) })
+	ts := httptest.NewServer(mux)
+	defer ts.Close()
+
+	code, out, errOut := runCmd(t, "search", "--catalog", ts.URL+"/index.json", "does")
+	if code != exitOK || out != "x  1.1.0  Does x\n" {
+		t.Errorf("search exit %d, stdout %q, stderr %q", code, out, errOut)
+	}
+	if code, _, errOut := runCmd(t, "search", "x"); code != exitUsage || !strings.Contains(errOut, "--catalog is required") {
+		t.Errorf("search without --catalog: exit %d: %s", code, errOut)
+	}
+
+	project := t.TempDir()
+	if code, _, errOut := runCmd(t, "install", "--agent", "claude-code", "--project", project, "--catalog", ts.URL+"/index.json", "x"); code != exitOK {
+		t.Fatalf("install exit %d: %s", code, errOut)
+	}
+	if _, err := os.Stat(filepath.Join(project, ".claude/skills/x/SKILL.md")); err != nil {
+		t.Error(err)
+	}
+	if code, _, errOut := runCmd(t, "install", "--agent", "claude-code", "--project", project, "--catalog", ts.URL+"/index.json", "x@1.0.0"); code != exitError {
+		t.Errorf("install of a missing archive: exit %d: %s", code, errOut)
+	}
+}
+
+func TestSignAndTrustedInstall(t *testing.T) {
+	dir, src := t.TempDir(), t.TempDir()
+	pub, priv, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, _ := x509.MarshalPKCS8PrivateKey(priv)
+	keyFile := filepath.Join(dir, "key.pem")
+	os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), 0o600)
+	der, _ = x509.MarshalPKIXPublicKey(pub)
+	pubFile := filepath.Join(dir, "key.pub.pem")
+	os.WriteFile(pubFile, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}), 0o644)
+	otherFile := filepath.Join(dir, "other.pub")
+	other, _, _ := ed25519.GenerateKey(nil)
+	os.WriteFile(otherFile, []byte(instill.EncodePublicKey(other)+"\n"), 0o644)
+
+	writeSkill(t, src, "x", "name: x\n")
+	if code, _, errOut := runCmd(t, "install", "--agent", "claude-code", "--project", t.TempDir(), "--trusted-key", pubFile, src); code != exitError || !strings.Contains(errOut, "no SKILL.sig") {
+		t.Errorf("unsigned install: exit %d: %s", code, errOut)
+	}
+	code, out, errOut := runCmd(t, "sign", "--key", keyFile, src)
+	if code != exitOK || out != "signed x with "+instill.EncodePublicKey(pub)+"\n" {
+		t.Fatalf("sign exit %d, stdout %q, stderr %q", code, out, errOut)
+	}
+	if code, _, errOut := runCmd(t, "install", "--agent", "claude-code", "--project", t.TempDir(), "--trusted-key", otherFile, src); code != exitError || !strings.Contains(errOut, "unknown key") {
+		t.Errorf("install with another key: exit %d: %s", code, errOut)
+	}
+	if code, _, errOut := runCmd(t, "install", "--agent", "claude-code", "--project", t.TempDir(), "--trusted-key", otherFile, "--trusted-key", pubFile, src); code != exitOK {
+		t.Errorf("trusted install: exit %d: %s", code, errOut)
+	}
+}
+
+func TestSymlinkInstall(t *testing.T) {
+	src, project := t.TempDir(), t.TempDir()
+	writeSkill(t, src, "x", "name: x\n")
+	if code, _, errOut := runCmd(t, "install", "--agent", "claude-code", "--project", project, "--symlink", src); code != exitOK {
+		t.Fatalf("install exit %d: %s", code, errOut)
+	}
+	if _, err := os.Readlink(filepath.Join(project, ".claude/skills/x")); err != nil {
+		t.Error(err)
+	}
+	code, out, _ := runCmd(t, "installed", "--agent", "claude-code", "--project", project, "--json")
+	if code != exitOK || !strings.Contains(out, `"Link": "`+filepath.Join(project, instill.StoreDir, "x")+`"`) {
+		t.Errorf("installed exit %d: %s", code, out)
+	}
+}
+
+func TestKeepGoing(t *testing.T) {
+	src, project := t.TempDir(), t.TempDir()
+	writeSkill(t, src, "x", "name: x\n")
+	os.MkdirAll(filepath.Join(project, ".windsurf"), 0o755)
+	os.WriteFile(filepath.Join(project, ".windsurf/skills"), nil, 0o644)
+	args := []string{"install", "--agent", "claude-code,windsurf", "--project", project}
+	if code, _, _ := runCmd(t, append(args, src)...); code != exitError {
+		t.Fatalf("install exit %d, want %d", code, exitError)
+	}
+	if _, err := os.Stat(filepath.Join(project, ".claude/skills/x")); !os.IsNotExist(err) {
+		t.Fatalf("failed install changed the project: %v", err)
+	}
+	code, out, _ := runCmd(t, append(args, "--keep-going", src)...)
+	if code != exitError || !strings.Contains(out, "installed  x") || !strings.Contains(out, "failed") {
+		t.Errorf("install --keep-going exit %d:\n%s", code, out)
+	}
+	if _, err := os.Stat(filepath.Join(project, ".claude/skills/x/SKILL.md")); err != nil {
+		t.Errorf("claude-code was not installed: %v", err)
+	}
+}
+
+func TestInstallTar(t *testing.T) {
+	src, dir := t.TempDir(), t.TempDir()
+	writeSkill(t, src, "x", "name: x\n")
+	archive := filepath.Join(dir, "skills.tar")
+	args := []string{"install", "--agent", "claude-code", "--project", "/work", "--tar-owner", "1000", "--tar-file-mode", "600"}
+	code, out, errOut := runCmd(t, append(args, "--tar", archive, src)...)
+	if code != exitOK || !strings.Contains(out, "/work/.claude/skills/x") {
+		t.Fatalf("install --tar exit %d: %s%s", code, out, errOut)
+	}
+	data, err := os.ReadFile(archive)
+	if err != nil {
+		t.Fatal(err)
+	}
+	code, out, _ = runCmd(t, append(args, "--tar", "-", src)...)
+	if code != exitOK || out != string(data) {
+		t.Errorf("install --tar - exit %d, output differs from the archive file", code)
+	}
+	tr := tar.NewReader(bytes.NewReader(data))
+	var names []string
+	for {
+		hdr, err := tr.Next()
+		if err != nil {
+			break
+		}
+		names = append(names, hdr.Name)
+		if hdr.Name == "work/.claude/skills/x/SKILL.md" && (hdr.Uid != 1000 || hdr.Gid != 1000 || hdr.Mode != 0o600) {
+			t.Errorf("SKILL.md entry = %+v", hdr)
+		}
+	}
+	if !slices.Contains(names, "work/.claude/skills/x/SKILL.md") {
+		t.Errorf("archive = %v", names)
+	}
+}
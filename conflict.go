@@ -0,0 +1,170 @@
+package instill
+
+import (
+	"fmt"
+	"io/fs"
+	"maps"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// ConflictPolicy decides what Install does with files in an installed skill
+// that were modified or added after instill wrote them.
+type ConflictPolicy int
+
+const (
+	ConflictOverwrite ConflictPolicy = iota // replace modified files and delete added ones
+	ConflictKeep                            // leave modified and added files untouched
+	ConflictBackup                          // save modified files as <name>.orig, then overwrite; keep added files
+	ConflictFail                            // refuse to install
+)
+
+// FileOutcome is what Install did with a conflicting file.
+type FileOutcome int
+
+const (
+	FileOverwritten FileOutcome = iota
+	FileDeleted
+	FileKept
+	FileBackedUp
+)
+
+func (o FileOutcome) String() string {
+	switch o {
+	case FileOverwritten:
+		return "overwritten"
+	case FileDeleted:
+		return "deleted"
+	case FileKept:
+		return "kept"
+	case FileBackedUp:
+		return "backed up"
+	}
+	return fmt.Sprintf("FileOutcome(%d)", int(o))
+}
+
+func (o FileOutcome) MarshalText() ([]byte, error) { return []byte(o.String()), nil }
+
+// FileConflict describes an installed file that differs from what instill wrote.
+type FileConflict struct {
+	File    string // path relative to the skill directory
+	Added   bool   // true if instill never wrote the file, false if it was modified
+	Outcome FileOutcome
+	Backup  string // relative path of the backup copy, for FileBackedUp
+}
+
+// ConflictError is returned under ConflictFail when an installed skill has
+// local changes.
+type ConflictError struct {
+	Path  string   // skill directory
+	Files []string // modified or added files, relative to Path
+}
+
+func (e *ConflictError) Error() string {
+	return fmt.Sprintf("instill: %s has local changes: %s", e.Path, strings.Join(e.Files, ", "))
+}
+
+const backupSuffix = ".orig"
+
+// resolveConflicts compares skillDir with the hashes recorded in prev when
+// instill last wrote it and applies policy. Backup copies are added to files;
+// the returned set lists paths that must be left untouched. Backups are the
+// user's: those recorded in prev are kept until a newer backup of the same
+// file replaces them, and every backup is returned for the next manifest.
+func resolveConflicts(fsys TargetFS, skillDir string, prev manifest, files map[string][]byte, policy ConflictPolicy) ([]FileConflict, map[string]bool, []string, error) {
+	recorded := prev.Files
+	if len(recorded) == 0 {
+		return nil, nil, nil, nil // not installed by instill, or before hashes were recorded
+	}
+	var conflicts []FileConflict
+	var saved []string // backups from earlier installs that still exist
+	err := walkDir(fsys, skillDir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil || !d.Type().IsRegular() {
+			return err
+		}
+		rel, err := filepath.Rel(skillDir, p)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if rel == manifestName {
+			return nil
+		}
+		if _, ok := files[rel]; !ok && slices.Contains(prev.Backups, rel) {
+			saved = append(saved, rel)
+			return nil
+		}
+		hash, ok := recorded[rel]
+		if ok {
+			data, err := fsys.ReadFile(p)
+			if err != nil {
+				return err
+			}
+			if hashBytes(data) == hash {
+				return nil
+			}
+		}
+		conflicts = append(conflicts, FileConflict{File: rel, Added: !ok})
+		return nil
+	})
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	if policy == ConflictFail && len(conflicts) > 0 {
+		e := &ConflictError{Path: skillDir}
+		for _, c := range conflicts {
+			e.Files = append(e.Files, c.File)
+		}
+		return nil, nil, nil, e
+	}
+
+	backups := map[string][]byte{}
+	for i := range conflicts {
+		c := &conflicts[i]
+		_, replaced := files[c.File]
+		switch {
+		case policy == ConflictKeep:
+			c.Outcome = FileKept
+		case policy == ConflictBackup && (replaced || !c.Added):
+			data, err := fsys.ReadFile(filepath.Join(skillDir, filepath.FromSlash(c.File)))
+			if err != nil {
+				return nil, nil, nil, err
+			}
+			c.Outcome = FileBackedUp
+			c.Backup = c.File + backupSuffix
+			backups[c.Backup] = data
+		case policy == ConflictBackup:
+			c.Outcome = FileKept
+		case replaced:
+			c.Outcome = FileOverwritten
+		default:
+			c.Outcome = FileDeleted
+		}
+	}
+	keep := map[string]bool{}
+	for i := range conflicts {
+		c := &conflicts[i]
+		if _, ok := backups[c.File]; ok && c.Outcome == FileKept {
+			c.Outcome = FileOverwritten // an older backup replaced by a newer one
+		}
+		if c.Outcome == FileKept {
+			keep[c.File] = true
+		}
+	}
+	for _, b := range saved {
+		if _, ok := backups[b]; !ok {
+			keep[b] = true
+		}
+	}
+	maps.Copy(files, backups)
+	all := slices.Collect(maps.Keys(backups))
+	for _, b := range saved {
+		if _, ok := backups[b]; !ok {
+			all = append(all, b)
+		}
+	}
+	slices.Sort(all)
+	return conflicts, keep, all, nil
+}
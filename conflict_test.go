@@ -0,0 +1,179 @@
+package instill
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// installWithLocalChanges installs skill x, then edits SKILL.md and adds
+// notes.md the way a user customizing the skill would.
+func installWithLocalChanges(t *testing.T) (Options, string) {
+	t.Helper()
+	tmp := t.TempDir()
+	opts := Options{Agents: []string{"claude-code"}, ProjectDir: tmp}
+	if _, err := Install(skillFSVersioned("x", "1.0"), opts); err != nil {
+		t.Fatal(err)
+	}
+	dir := filepath.Join(tmp, ".claude/skills/x")
+	if err := os.WriteFile(filepath.Join(dir, "SKILL.md"), []byte("---\nname: x\n---\nmine"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "notes.md"), []byte("notes"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return opts, dir
+}
+
+func readString(t *testing.T, p string) string {
+	t.Helper()
+	data, err := os.ReadFile(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func outcomes(r Result) map[string]FileOutcome {
+	m := map[string]FileOutcome{}
+	for _, c := range r.Conflicts {
+		m[c.File] = c.Outcome
+	}
+	return m
+}
+
+func TestInstallConflictOverwrite(t *testing.T) {
+	opts, dir := installWithLocalChanges(t)
+	results, err := Install(skillFSVersioned("x", "2.0"), opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := outcomes(results[0])
+	if got["SKILL.md"] != FileOverwritten || got["notes.md"] != FileDeleted || len(got) != 2 {
+		t.Errorf("unexpected conflicts: %+v", results[0].Conflicts)
+	}
+	if v := installedVersionAt(OSFS{}, dir); v != "2.0" {
+		t.Errorf("version = %q, want 2.0", v)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "notes.md")); !os.IsNotExist(err) {
+		t.Error("notes.md should be deleted")
+	}
+}
+
+func TestInstallConflictKeep(t *testing.T) {
+	opts, dir := installWithLocalChanges(t)
+	opts.Conflict = ConflictKeep
+	results, err := Install(skillFSVersioned("x", "2.0"), opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := outcomes(results[0])
+	if got["SKILL.md"] != FileKept || got["notes.md"] != FileKept {
+		t.Errorf("unexpected conflicts: %+v", results[0].Conflicts)
+	}
+	if s := readString(t, filepath.Join(dir, "SKILL.md")); s != "---\nname: x\n---\nmine" {
+		t.Errorf("SKILL.md was not kept: %q", s)
+	}
+	if s := readString(t, filepath.Join(dir, "notes.md")); s != "notes" {
+		t.Errorf("notes.md was not kept: %q", s)
+	}
+
+	// Still reported as modified on the next install.
+	results, err = Install(skillFSVersioned("x", "2.0"), opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results[0].Conflicts) != 2 {
+		t.Errorf("expected conflicts to persist, got %+v", results[0].Conflicts)
+	}
+}
+
+func TestInstallConflictBackup(t *testing.T) {
+	opts, dir := installWithLocalChanges(t)
+	opts.Conflict = ConflictBackup
+	results, err := Install(skillFSVersioned("x", "2.0"), opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := outcomes(results[0])
+	if got["SKILL.md"] != FileBackedUp || got["notes.md"] != FileKept {
+		t.Errorf("unexpected conflicts: %+v", results[0].Conflicts)
+	}
+	if s := readString(t, filepath.Join(dir, "SKILL.md.orig")); s != "---\nname: x\n---\nmine" {
+		t.Errorf("backup = %q", s)
+	}
+	if v := installedVersionAt(OSFS{}, dir); v != "2.0" {
+		t.Errorf("version = %q, want 2.0", v)
+	}
+
+	// A second round of edits replaces the old backup instead of chaining.
+	if err := os.WriteFile(filepath.Join(dir, "SKILL.md"), []byte("mine again"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Install(skillFSVersioned("x", "2.0"), opts); err != nil {
+		t.Fatal(err)
+	}
+	if s := readString(t, filepath.Join(dir, "SKILL.md.orig")); s != "mine again" {
+		t.Errorf("backup = %q", s)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "SKILL.md.orig.orig")); !os.IsNotExist(err) {
+		t.Error("backups should not chain")
+	}
+}
+
+func TestInstallKeepsBackups(t *testing.T) {
+	opts, dir := installWithLocalChanges(t)
+	opts.Conflict = ConflictBackup
+	if _, err := Install(skillFSVersioned("x", "2.0"), opts); err != nil {
+		t.Fatal(err)
+	}
+
+	// Later installs with the default policy leave the user's backup alone.
+	opts.Conflict = ConflictOverwrite
+	for _, version := range []string{"3.0", "4.0"} {
+		results, err := Install(skillFSVersioned("x", version), opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, ok := outcomes(results[0])["SKILL.md.orig"]; ok {
+			t.Errorf("%s: backup reported as a conflict: %+v", version, results[0].Conflicts)
+		}
+		if s := readString(t, filepath.Join(dir, "SKILL.md.orig")); s != "---\nname: x\n---\nmine" {
+			t.Errorf("%s: backup = %q", version, s)
+		}
+	}
+	if got := readManifest(OSFS{}, dir, discard).Backups; len(got) != 1 || got[0] != "SKILL.md.orig" {
+		t.Errorf("manifest backups = %v", got)
+	}
+}
+
+func TestInstallConflictFail(t *testing.T) {
+	opts, dir := installWithLocalChanges(t)
+	opts.Conflict = ConflictFail
+	_, err := Install(skillFSVersioned("x", "2.0"), opts)
+	var ce *ConflictError
+	if !errors.As(err, &ce) {
+		t.Fatalf("expected ConflictError, got %v", err)
+	}
+	if len(ce.Files) != 2 || ce.Files[0] != "SKILL.md" || ce.Files[1] != "notes.md" {
+		t.Errorf("Files = %v", ce.Files)
+	}
+	if s := readString(t, filepath.Join(dir, "SKILL.md")); s != "---\nname: x\n---\nmine" {
+		t.Error("nothing should change on conflict failure")
+	}
+}
+
+func TestInstallNoConflictsWhenUnchanged(t *testing.T) {
+	tmp := t.TempDir()
+	opts := Options{Agents: []string{"claude-code"}, ProjectDir: tmp, Conflict: ConflictFail}
+	for range 2 {
+		results, err := Install(skillFSWithRef("x"), opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(results[0].Conflicts) != 0 {
+			t.Errorf("unexpected conflicts: %+v", results[0].Conflicts)
+		}
+	}
+}
@@ -0,0 +1,42 @@
+package instill
+
+import (
+	"fmt"
+	"strings"
+)
+
+// UnknownAgentError is returned when Options.Agents names an agent that is
+// neither built in nor registered.
+type UnknownAgentError struct {
+	Name string
+}
+
+func (e *UnknownAgentError) Error() string {
+	return fmt.Sprintf("instill: unknown agent %q", e.Name)
+}
+
+// InvalidSkillError is returned when a SKILL.md in the source cannot be
+// parsed or lacks a name.
+type InvalidSkillError struct {
+	Path string // SKILL.md, relative to the source
+	Err  error
+}
+
+func (e *InvalidSkillError) Error() string { return fmt.Sprintf("%s: %v", e.Path, e.Err) }
+
+func (e *InvalidSkillError) Unwrap() error { return e.Err }
+
+// TargetError reports why an operation failed for the agents sharing a skills
+// directory, under Options.ContinueOnError. Err wraps the underlying cause,
+// so that errors.Is(err, fs.ErrPermission) reports a read-only directory.
+type TargetError struct {
+	Agents []string
+	Dir    string // skills directory
+	Err    error
+}
+
+func (e *TargetError) Error() string {
+	return fmt.Sprintf("instill: %s (%s): %v", strings.Join(e.Agents, ", "), e.Dir, strings.TrimPrefix(e.Err.Error(), "instill: "))
+}
+
+func (e *TargetError) Unwrap() error { return e.Err }
@@ -0,0 +1,94 @@
+package instill
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+)
+
+func TestContinueOnError(t *testing.T) {
+	project := t.TempDir()
+	// A file where windsurf's skills directory belongs makes its install fail.
+	os.MkdirAll(filepath.Join(project, ".windsurf"), 0o755)
+	os.WriteFile(filepath.Join(project, ".windsurf/skills"), nil, 0o644)
+	opts := Options{Agents: []string{"claude-code", "nope", "windsurf"}, ProjectDir: project}
+
+	if _, err := Install(linkedSkills, opts); err == nil {
+		t.Fatal("Install succeeded despite an unknown agent")
+	}
+	if _, err := os.Stat(filepath.Join(project, ".claude")); !os.IsNotExist(err) {
+		t.Fatalf("failed Install changed the project: %v", err)
+	}
+
+	opts.ContinueOnError = true
+	results, err := Install(linkedSkills, opts)
+	var unknown *UnknownAgentError
+	if !errors.As(err, &unknown) || unknown.Name != "nope" {
+		t.Errorf("err = %v, want an UnknownAgentError for nope", err)
+	}
+	var target *TargetError
+	if !errors.As(err, &target) || target.Dir != filepath.Join(project, ".windsurf/skills") || len(target.Agents) != 1 || target.Agents[0] != "windsurf" {
+		t.Errorf("err = %v, want a TargetError for windsurf", err)
+	}
+	byAgent := map[string]Result{}
+	for _, r := range results {
+		byAgent[r.Agent] = r
+	}
+	if len(results) != 3 {
+		t.Fatalf("got %d results, want 3: %+v", len(results), results)
+	}
+	if r := byAgent["claude-code"]; r.Err != nil || r.Skill != "alpha" {
+		t.Errorf("claude-code: %+v", r)
+	}
+	if r := byAgent["nope"]; !errors.As(r.Err, &unknown) {
+		t.Errorf("nope: Err = %v", r.Err)
+	}
+	if r := byAgent["windsurf"]; !errors.As(r.Err, &target) || r.Skill != "alpha" {
+		t.Errorf("windsurf: %+v", r)
+	}
+	if _, err := os.Stat(filepath.Join(project, ".claude/skills/alpha/SKILL.md")); err != nil {
+		t.Errorf("claude-code was not installed: %v", err)
+	}
+
+	results, err = Remove("alpha", opts)
+	if !errors.As(err, &unknown) || errors.As(err, &target) {
+		t.Errorf("Remove: err = %v, want only the unknown agent", err)
+	}
+	if len(results) != 3 {
+		t.Errorf("Remove: got %d results, want 3", len(results))
+	}
+	if _, err := os.Stat(filepath.Join(project, ".claude/skills/alpha")); !os.IsNotExist(err) {
+		t.Errorf("claude-code was not removed: %v", err)
+	}
+}
+
+func TestContinueOnErrorPermission(t *testing.T) {
+	if os.Geteuid() == 0 {
+		t.Skip("permissions are not enforced for root")
+	}
+	project := t.TempDir()
+	readOnly := filepath.Join(project, ".windsurf/skills")
+	os.MkdirAll(readOnly, 0o755)
+	os.Chmod(readOnly, 0o555)
+	t.Cleanup(func() { os.Chmod(readOnly, 0o755) })
+
+	results, err := Install(linkedSkills, Options{Agents: []string{"claude-code", "windsurf"}, ProjectDir: project, ContinueOnError: true})
+	if !errors.Is(err, fs.ErrPermission) {
+		t.Errorf("err = %v, want fs.ErrPermission", err)
+	}
+	if len(results) != 2 || results[0].Err != nil || !errors.Is(results[1].Err, fs.ErrPermission) {
+		t.Errorf("results = %+v", results)
+	}
+}
+
+func TestInvalidSkillError(t *testing.T) {
+	src := fstest.MapFS{"broken/SKILL.md": {Data: []byte("---\ndescription: no name\n---\n")}}
+	_, err := Install(src, Options{Agents: []string{"claude-code"}, ProjectDir: t.TempDir()})
+	var invalid *InvalidSkillError
+	if !errors.As(err, &invalid) || invalid.Path != "broken/SKILL.md" {
+		t.Errorf("err = %v, want an InvalidSkillError for broken/SKILL.md", err)
+	}
+}
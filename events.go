@@ -0,0 +1,74 @@
+package instill
+
+import "fmt"
+
+// EventKind identifies a step reported to Options.Progress.
+type EventKind int
+
+const (
+	EventSkill    EventKind = iota // a skill was found in the source
+	EventTarget                    // an agent's skills directory was resolved
+	EventSkip                      // a skill, agent or file is left as it is; see Event.Reason
+	EventWrite                     // a file was written or a skill directory linked
+	EventDelete                    // a file or directory was deleted
+	EventCommand                   // a command file was written
+	EventSubagent                  // a subagent file was written
+)
+
+func (k EventKind) String() string {
+	switch k {
+	case EventSkill:
+		return "skill"
+	case EventTarget:
+		return "target"
+	case EventSkip:
+		return "skip"
+	case EventWrite:
+		return "write"
+	case EventDelete:
+		return "delete"
+	case EventCommand:
+		return "command"
+	case EventSubagent:
+		return "subagent"
+	}
+	return fmt.Sprintf("EventKind(%d)", int(k))
+}
+
+func (k EventKind) MarshalText() ([]byte, error) { return []byte(k.String()), nil }
+
+// Event is a step of an operation, reported to Options.Progress as it
+// happens. EventSkill, EventTarget and EventSkip are reported while planning;
+// the others while a plan is applied, before it is committed: if applying
+// then fails or is cancelled, every change is rolled back regardless.
+type Event struct {
+	Kind   EventKind
+	Skill  string
+	Agent  string // agent the step is for, if any
+	Path   string // skills directory for EventTarget; file or directory otherwise
+	Reason string // why, for EventSkip
+	Done   int    // operations of the plan applied so far, while applying
+	Total  int    // operations in the plan, while applying
+}
+
+// emit reports e to progress, if set.
+func emit(progress func(Event), e Event) {
+	if progress != nil {
+		progress(e)
+	}
+}
+
+// event returns the event reporting op once it is applied, and false for
+// operations not worth reporting.
+func (op Op) event() (Event, bool) {
+	e := Event{Kind: EventWrite, Skill: op.Skill, Agent: op.agent, Path: op.Path}
+	switch {
+	case op.Kind == OpMkdir:
+		return e, false
+	case op.Kind == OpDelete:
+		e.Kind = EventDelete
+	case op.extra == EventCommand, op.extra == EventSubagent:
+		e.Kind = op.extra
+	}
+	return e, true
+}
@@ -0,0 +1,117 @@
+package instill
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+)
+
+func TestProgressEvents(t *testing.T) {
+	project := t.TempDir()
+	src := fstest.MapFS{
+		"alpha/SKILL.md":         {Data: []byte("---\nname: alpha\n---\n")},
+		"alpha/_commands/run.md": {Data: []byte("Run!\n")},
+		"beta/SKILL.md":          {Data: []byte("---\nname: beta\n---\n")},
+	}
+	var events []Event
+	opts := Options{Agents: []string{"claude-code"}, Skills: []string{"alpha"}, ProjectDir: project, Progress: func(e Event) { events = append(events, e) }}
+	if _, err := Install(src, opts); err != nil {
+		t.Fatal(err)
+	}
+
+	seen := map[EventKind][]Event{}
+	done := 0
+	for _, e := range events {
+		seen[e.Kind] = append(seen[e.Kind], e)
+		if e.Total > 0 {
+			if e.Done <= done || e.Done > e.Total {
+				t.Errorf("%s %s: Done = %d of %d after %d", e.Kind, e.Path, e.Done, e.Total, done)
+			}
+			done = e.Done
+		}
+	}
+	skillsDir := filepath.Join(project, ".claude/skills")
+	if got := seen[EventTarget]; len(got) != 1 || got[0].Agent != "claude-code" || got[0].Path != skillsDir {
+		t.Errorf("target events = %+v", got)
+	}
+	if got := seen[EventSkill]; len(got) != 1 || got[0].Skill != "alpha" {
+		t.Errorf("skill events = %+v", got)
+	}
+	if got := seen[EventSkip]; len(got) != 1 || got[0].Skill != "beta" || got[0].Reason != "not selected" {
+		t.Errorf("skip events = %+v", got)
+	}
+	if got := seen[EventCommand]; len(got) != 1 || got[0].Agent != "claude-code" || got[0].Path != filepath.Join(project, ".claude/commands/run.md") {
+		t.Errorf("command events = %+v", got)
+	}
+	if got := seen[EventWrite]; len(got) != 2 || got[0].Path != filepath.Join(skillsDir, "alpha", manifestName) || got[1].Path != filepath.Join(skillsDir, "alpha/SKILL.md") {
+		t.Errorf("write events = %+v", got)
+	}
+
+	events = nil
+	opts.Agents = []string{"claude-code", "windsurf"}
+	if _, err := Remove("alpha", opts); err != nil {
+		t.Fatal(err)
+	}
+	var kinds []EventKind
+	for _, e := range events {
+		kinds = append(kinds, e.Kind)
+	}
+	want := []EventKind{EventTarget, EventTarget, EventSkip, EventDelete, EventDelete}
+	if len(kinds) != len(want) {
+		t.Fatalf("remove events = %v, want %v", kinds, want)
+	}
+	for i := range want {
+		if kinds[i] != want[i] {
+			t.Fatalf("remove events = %v, want %v", kinds, want)
+		}
+	}
+	if events[2].Agent != "windsurf" || events[2].Reason != "not installed" {
+		t.Errorf("skip event = %+v", events[2])
+	}
+}
+
+func TestInstallCancelled(t *testing.T) {
+	project := t.TempDir()
+	opts := Options{Agents: []string{"claude-code", "windsurf"}, ProjectDir: project}
+	v1 := fstest.MapFS{"alpha/SKILL.md": {Data: []byte("---\nname: alpha\nversion: 1.0.0\n---\n")}}
+	if _, err := Install(v1, opts); err != nil {
+		t.Fatal(err)
+	}
+
+	// Cancel once the first file of the update is staged.
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	opts.Progress = func(e Event) {
+		if e.Kind == EventWrite {
+			cancel()
+		}
+	}
+	_, err := InstallContext(ctx, linkedSkills, opts)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("err = %v, want context.Canceled", err)
+	}
+	for _, dir := range []string{".claude/skills/alpha", ".windsurf/skills/alpha"} {
+		if v := installedVersionAt(OSFS{}, filepath.Join(project, dir)); v != "1.0.0" {
+			t.Errorf("%s: version %q after cancelled update, want 1.0.0", dir, v)
+		}
+	}
+	for _, p := range []string{".claude/skills/alpha/references", ".claude/commands"} {
+		if _, err := os.Stat(filepath.Join(project, p)); !os.IsNotExist(err) {
+			t.Errorf("%s left behind by cancelled update: %v", p, err)
+		}
+	}
+	entries, _ := os.ReadDir(filepath.Join(project, ".claude/skills"))
+	if len(entries) != 1 {
+		t.Errorf(".claude/skills has %d entries after rollback, want 1", len(entries))
+	}
+
+	if _, err := RemoveContext(ctx, "alpha", opts); !errors.Is(err, context.Canceled) {
+		t.Errorf("RemoveContext with cancelled context: err = %v", err)
+	}
+	if _, err := DetectContext(ctx, project, false); !errors.Is(err, context.Canceled) {
+		t.Errorf("DetectContext with cancelled context: err = %v", err)
+	}
+}
@@ -0,0 +1,157 @@
+package instill
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+)
+
+// DefaultLockTimeout is how long operations wait for other processes working
+// on the same directories when Options.LockTimeout is zero.
+const DefaultLockTimeout = 30 * time.Second
+
+// ErrLocked is returned, wrapped, when another process holds the lock on a
+// directory an operation needs for longer than Options.LockTimeout.
+var ErrLocked = errors.New("directory is locked by another process")
+
+// lockTimeout returns the timeout set in opts, or its default.
+func lockTimeout(opts Options) time.Duration {
+	if opts.LockTimeout == 0 {
+		return DefaultLockTimeout
+	}
+	return max(opts.LockTimeout, 0)
+}
+
+// lockDirsFor returns the directories an operation with opts may change: the
+// skills directories of the target agents, the canonical store when links to
+// it may be made or released, the legacy store if it exists, and the project
+// root when the LockFile is updated. Commands and subagents belong to the same agents, so the skills
+// directory stands in for them. Nothing is locked when Options.FS is not the
+// local disk.
+func lockDirsFor(set *agentSet, opts Options) ([]string, error) {
+	targets, err := resolveTargets(set, opts)
+	if err != nil || !isOS(opts.targetFS()) {
+		return nil, err
+	}
+	dirs := slices.Collect(maps.Keys(targets))
+	if _, err := os.Stat(storeDir(opts)); err == nil || opts.Symlink {
+		dirs = append(dirs, storeDir(opts))
+	}
+	if legacy := scopeDir(legacyStoreDir, opts); !slices.Contains(dirs, legacy) {
+		if _, err := os.Stat(legacy); err == nil {
+			dirs = append(dirs, legacy)
+		}
+	}
+	if opts.Lock {
+		dirs = append(dirs, opts.ProjectDir)
+	}
+	return dirs, nil
+}
+
+// lockTargets locks the directories an operation with opts may change; see
+// lockDirsFor.
+func lockTargets(ctx context.Context, opts Options) (func(), error) {
+	set, err := agentsFor(opts.ProjectDir)
+	if err != nil {
+		return nil, err
+	}
+	dirs, err := lockDirsFor(set, opts)
+	if err != nil {
+		return nil, err
+	}
+	return lockDirs(ctx, dirs, lockTimeout(opts))
+}
+
+// lockDirs takes an advisory, exclusive lock on each of dirs, waiting up to
+// timeout for other processes to release them or until ctx is cancelled,
+// and returns a function that releases them all. Locks are taken in a fixed
+// order, so that processes locking overlapping sets cannot deadlock.
+//
+// The lock files live outside the directories, in the user's cache
+// directory, so that locking neither creates the directories nor leaves
+// files behind in them.
+func lockDirs(ctx context.Context, dirs []string, timeout time.Duration) (func(), error) {
+	keys := map[string]string{} // lock file name → directory
+	for _, dir := range dirs {
+		abs, err := filepath.Abs(dir)
+		if err != nil {
+			return nil, fmt.Errorf("instill: locking %s: %w", dir, err)
+		}
+		sum := sha256.Sum256([]byte(abs))
+		keys[hex.EncodeToString(sum[:16])+".lock"] = abs
+	}
+	if len(keys) == 0 {
+		return func() {}, nil
+	}
+	root, err := lockRoot()
+	if err != nil {
+		return nil, err
+	}
+	var held []*os.File
+	unlock := func() {
+		for _, f := range slices.Backward(held) {
+			f.Close()
+		}
+	}
+	deadline := time.Now().Add(timeout)
+	for _, name := range slices.Sorted(maps.Keys(keys)) {
+		f, err := lockFile(ctx, filepath.Join(root, name), keys[name], deadline)
+		if err != nil {
+			unlock()
+			return nil, err
+		}
+		held = append(held, f)
+	}
+	return unlock, nil
+}
+
+// lockRoot returns the directory holding lock files, creating it if needed.
+func lockRoot() (string, error) {
+	base, err := os.UserCacheDir()
+	if err != nil {
+		base = os.TempDir()
+	}
+	root := filepath.Join(base, "instill", "locks")
+	if err := os.MkdirAll(root, 0o755); err != nil {
+		return "", fmt.Errorf("instill: creating lock directory: %w", err)
+	}
+	return root, nil
+}
+
+// lockFile opens the lock file at p and locks it for dir, polling until
+// deadline while another process holds it. Closing the file releases the lock.
+func lockFile(ctx context.Context, p, dir string, deadline time.Time) (*os.File, error) {
+	f, err := os.OpenFile(p, os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("instill: locking %s: %w", dir, err)
+	}
+	for delay := 10 * time.Millisecond; ; delay = min(2*delay, 250*time.Millisecond) {
+		ok, err := tryLock(f)
+		if err != nil {
+			f.Close()
+			return nil, fmt.Errorf("instill: locking %s: %w", dir, err)
+		}
+		if ok {
+			return f, nil
+		}
+		if !time.Now().Before(deadline) {
+			f.Close()
+			return nil, fmt.Errorf("instill: %s: %w (lock file %s); try again once it finishes or raise the lock timeout", dir, ErrLocked, p)
+		}
+		t := time.NewTimer(min(delay, time.Until(deadline)))
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			f.Close()
+			return nil, ctx.Err()
+		case <-t.C:
+		}
+	}
+}
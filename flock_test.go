@@ -0,0 +1,101 @@
+package instill
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func TestInstallLocked(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	project := t.TempDir()
+	opts := Options{Agents: []string{"claude-code"}, ProjectDir: project, LockTimeout: 50 * time.Millisecond}
+	unlock, err := lockTargets(context.Background(), opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	start := time.Now()
+	_, err = Install(linkedSkills, opts)
+	if !errors.Is(err, ErrLocked) {
+		t.Fatalf("Install while locked: err = %v, want ErrLocked", err)
+	}
+	if waited := time.Since(start); waited < opts.LockTimeout {
+		t.Errorf("gave up after %s, before the %s timeout", waited, opts.LockTimeout)
+	}
+	if _, err := os.Stat(filepath.Join(project, ".claude")); !os.IsNotExist(err) {
+		t.Errorf("locked install changed the project: %v", err)
+	}
+	plan, err := PlanRemove("alpha", opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := plan.Apply(); !errors.Is(err, ErrLocked) {
+		t.Errorf("Apply while locked: err = %v, want ErrLocked", err)
+	}
+
+	// Operations on other directories are not held up.
+	other := opts
+	other.Agents = []string{"windsurf"}
+	if _, err := Install(linkedSkills, other); err != nil {
+		t.Errorf("Install for another agent: %v", err)
+	}
+
+	unlock()
+	if _, err := Install(linkedSkills, opts); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestInstallWaitsForLock(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	opts := Options{Agents: []string{"claude-code"}, ProjectDir: t.TempDir()}
+	unlock, err := lockTargets(context.Background(), opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	time.AfterFunc(50*time.Millisecond, unlock)
+	if _, err := Install(linkedSkills, opts); err != nil {
+		t.Fatalf("Install did not wait for the lock: %v", err)
+	}
+}
+
+func TestConcurrentInstalls(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	project := t.TempDir()
+	opts := Options{Agents: []string{"claude-code", "cursor"}, ProjectDir: project, Lock: true}
+	var wg sync.WaitGroup
+	errs := make([]error, 8)
+	for i := range errs {
+		wg.Go(func() {
+			v := fmt.Sprintf("1.%d.0", i)
+			src := fstest.MapFS{
+				"alpha/SKILL.md":        {Data: []byte("---\nname: alpha\nversion: " + v + "\n---\n")},
+				"alpha/references/v.md": {Data: []byte(v + "\n")},
+			}
+			_, errs[i] = Install(src, opts)
+		})
+	}
+	wg.Wait()
+	if err := errors.Join(errs...); err != nil {
+		t.Fatal(err)
+	}
+	for _, dir := range []string{".claude/skills/alpha", ".agents/skills/alpha"} {
+		skillDir := filepath.Join(project, dir)
+		v := installedVersionAt(OSFS{}, skillDir)
+		ref, err := os.ReadFile(filepath.Join(skillDir, "references/v.md"))
+		if err != nil || string(ref) != v+"\n" {
+			t.Errorf("%s: SKILL.md is %s but references/v.md is %q (%v)", dir, v, ref, err)
+		}
+	}
+	drift, err := Verify(opts)
+	if err != nil || len(drift) != 0 {
+		t.Errorf("Verify = %+v, %v", drift, err)
+	}
+}
@@ -0,0 +1,160 @@
+package instill
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// splitFrontmatter returns the YAML between the opening and closing --- lines
+// of a SKILL.md, and the 1-based line number the YAML starts on.
+func splitFrontmatter(data []byte) ([]byte, int, error) {
+	data = bytes.TrimPrefix(data, []byte("\ufeff"))
+	lines := bytes.SplitAfter(data, []byte("\n"))
+	start := 0
+	for start < len(lines) && len(bytes.TrimSpace(lines[start])) == 0 {
+		start++
+	}
+	if start == len(lines) || !isDelimiter(lines[start]) {
+		return nil, 0, fmt.Errorf("missing frontmatter (must start with ---)")
+	}
+	for i := start + 1; i < len(lines); i++ {
+		if isDelimiter(lines[i]) {
+			return bytes.Join(lines[start+1:i], nil), start + 2, nil
+		}
+	}
+	return nil, 0, fmt.Errorf("malformed frontmatter: missing closing ---")
+}
+
+func isDelimiter(line []byte) bool {
+	return string(bytes.TrimRight(line, " \t\r\n")) == "---"
+}
+
+var errFrontmatterYAML = errors.New("invalid frontmatter YAML")
+
+// decodeFrontmatter parses the frontmatter of a SKILL.md into a YAML mapping.
+func decodeFrontmatter(data []byte) (*yamlNode, error) {
+	fm, line, err := splitFrontmatter(data)
+	if err != nil {
+		return nil, err
+	}
+	root, err := parseYAML(string(fm), line)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", errFrontmatterYAML, err)
+	}
+	if root.isNull() {
+		return &yamlNode{kind: yamlMapping, line: line}, nil
+	}
+	if root.kind != yamlMapping {
+		return nil, fmt.Errorf("invalid frontmatter: expected a mapping of fields")
+	}
+	return root, nil
+}
+
+// parseFrontmatter parses the frontmatter of a SKILL.md into SkillMeta.
+// Names are returned as written; callers sanitize them where needed.
+func parseFrontmatter(data []byte) (SkillMeta, error) {
+	root, err := decodeFrontmatter(data)
+	if err != nil {
+		if errors.Is(err, errFrontmatterYAML) {
+			fm, _, _ := splitFrontmatter(data)
+			return parseFrontmatterLines(fm), nil
+		}
+		return SkillMeta{}, err
+	}
+	var m SkillMeta
+	for i := 0; i+1 < len(root.content); i += 2 {
+		key, val := root.content[i].value, root.content[i+1]
+		switch key {
+		case "name", "version", "description", "license", "compatibility":
+			// Values of the wrong type are skipped here and reported by Validate.
+			s, _ := scalar(val)
+			switch key {
+			case "name":
+				m.Name = s
+			case "version":
+				m.Version = s
+			case "description":
+				m.Description = s
+			case "license":
+				m.License = s
+			case "compatibility":
+				m.Compatibility = s
+			}
+		case "allowed-tools":
+			switch val.kind {
+			case yamlScalar:
+				m.AllowedTools = strings.Fields(val.value)
+			case yamlSequence:
+				for _, item := range val.content {
+					if s, ok := scalar(item); ok && s != "" {
+						m.AllowedTools = append(m.AllowedTools, s)
+					}
+				}
+			}
+		case "metadata":
+			if val.kind != yamlMapping {
+				continue
+			}
+			m.Metadata = make(map[string]string, len(val.content)/2)
+			for j := 0; j+1 < len(val.content); j += 2 {
+				if s, ok := scalar(val.content[j+1]); ok {
+					m.Metadata[val.content[j].value] = s
+				}
+			}
+		default:
+			if m.Extra == nil {
+				m.Extra = map[string]any{}
+			}
+			m.Extra[key] = val.decode()
+		}
+	}
+	if m.Version == "" {
+		m.Version = m.Metadata["version"]
+	}
+	return m, nil
+}
+
+// scalar returns the text of a scalar node exactly as written, so that
+// values like 1.0 are not reinterpreted as numbers. ok is false for lists
+// and mappings.
+func scalar(n *yamlNode) (s string, ok bool) {
+	if n.kind != yamlScalar {
+		return "", false
+	}
+	if n.isNull() {
+		return "", true
+	}
+	return strings.TrimSpace(n.value), true
+}
+
+// parseFrontmatterLines reads top-level "key: value" lines from frontmatter
+// that is not valid YAML, such as unquoted descriptions containing ": ",
+// which agents commonly accept.
+func parseFrontmatterLines(fm []byte) SkillMeta {
+	var m SkillMeta
+	for _, line := range strings.Split(string(fm), "\n") {
+		if line == "" || line[0] == ' ' || line[0] == '\t' {
+			continue
+		}
+		k, v, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		v = strings.Trim(strings.TrimSpace(v), `"'`)
+		switch strings.TrimSpace(k) {
+		case "name":
+			m.Name = v
+		case "version":
+			m.Version = v
+		case "description":
+			m.Description = v
+		case "license":
+			m.License = v
+		case "compatibility":
+			m.Compatibility = v
+		}
+	}
+	return m
+}
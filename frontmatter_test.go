@@ -0,0 +1,115 @@
+package instill
+
+import (
+	"slices"
+	"testing"
+	"testing/fstest"
+)
+
+func TestParseFrontmatter(t *testing.T) {
+	data := []byte(`---
+name: pdf-processing
+description: >
+  Extract text and tables from PDF files,
+  fill forms and merge documents.
+license: Apache-2.0
+compatibility: "Requires python3 and poppler"
+allowed-tools: Bash(git:*) Read
+version: 1.0
+metadata:
+  author: example-org
+  tags: "pdf, docs"
+notes: |
+  ---
+  not a delimiter
+custom:
+  nested: [a, b]
+---
+
+# PDF processing
+`)
+	m, err := parseFrontmatter(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.Name != "pdf-processing" {
+		t.Errorf("Name = %q", m.Name)
+	}
+	if m.Description != "Extract text and tables from PDF files, fill forms and merge documents." {
+		t.Errorf("Description = %q", m.Description)
+	}
+	if m.License != "Apache-2.0" || m.Compatibility != "Requires python3 and poppler" {
+		t.Errorf("License = %q, Compatibility = %q", m.License, m.Compatibility)
+	}
+	if !slices.Equal(m.AllowedTools, []string{"Bash(git:*)", "Read"}) {
+		t.Errorf("AllowedTools = %q", m.AllowedTools)
+	}
+	if m.Version != "1.0" {
+		t.Errorf("Version = %q, want the literal 1.0", m.Version)
+	}
+	if m.Metadata["author"] != "example-org" || m.Metadata["tags"] != "pdf, docs" {
+		t.Errorf("Metadata = %v", m.Metadata)
+	}
+	if m.Extra["notes"] != "---\nnot a delimiter\n" {
+		t.Errorf("Extra[notes] = %q", m.Extra["notes"])
+	}
+	if _, ok := m.Extra["custom"].(map[string]any); !ok {
+		t.Errorf("Extra[custom] = %#v", m.Extra["custom"])
+	}
+}
+
+func TestParseFrontmatterVariants(t *testing.T) {
+	for _, tt := range []struct {
+		name  string
+		input string
+		want  SkillMeta
+	}{
+		{"allowed-tools list", "---\nname: x\nallowed-tools:\n  - Read\n  - Write\n---\n", SkillMeta{Name: "x", AllowedTools: []string{"Read", "Write"}}},
+		{"version in metadata", "---\nname: x\nmetadata:\n  version: \"2.1\"\n---\n", SkillMeta{Name: "x", Version: "2.1", Metadata: map[string]string{"version": "2.1"}}},
+		{"literal description", "---\nname: x\ndescription: |\n  line one\n  line two\n---\n", SkillMeta{Name: "x", Description: "line one\nline two"}},
+		{"CRLF line endings", "---\r\nname: x\r\nversion: '3'\r\n---\r\nbody", SkillMeta{Name: "x", Version: "3"}},
+		{"wrong types are skipped", "---\nname: x\nlicense: [MIT]\nallowed-tools:\n  - Read\n  - {tool: Write}\nmetadata:\n  tags: [a, b]\n  author: me\n---\n", SkillMeta{Name: "x", AllowedTools: []string{"Read"}, Metadata: map[string]string{"author": "me"}}},
+		{"unquoted colon falls back", "---\nname: x\ndescription: Use when: asked\n---\n", SkillMeta{Name: "x", Description: "Use when: asked"}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseFrontmatter([]byte(tt.input))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.Name != tt.want.Name || got.Version != tt.want.Version || got.Description != tt.want.Description {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+			if !slices.Equal(got.AllowedTools, tt.want.AllowedTools) {
+				t.Errorf("AllowedTools = %q, want %q", got.AllowedTools, tt.want.AllowedTools)
+			}
+			if len(got.Metadata) != len(tt.want.Metadata) {
+				t.Errorf("Metadata = %v, want %v", got.Metadata, tt.want.Metadata)
+			}
+		})
+	}
+}
+
+func TestParseFrontmatterErrors(t *testing.T) {
+	for _, input := range []string{
+		"no frontmatter",
+		"---\nname: x\n",
+		"---\n- a\n- b\n---\n",
+	} {
+		if _, err := parseFrontmatter([]byte(input)); err == nil {
+			t.Errorf("parseFrontmatter(%q): expected error", input)
+		}
+	}
+}
+
+func TestListSkillsFullMeta(t *testing.T) {
+	fsys := fstest.MapFS{
+		"a/SKILL.md": &fstest.MapFile{Data: []byte("---\nname: A Skill\nlicense: MIT\nallowed-tools: Read\n---\n")},
+	}
+	skills := ListSkills(fsys)
+	if len(skills) != 1 {
+		t.Fatalf("got %d skills", len(skills))
+	}
+	if skills[0].Name != "a-skill" || skills[0].License != "MIT" || !slices.Equal(skills[0].AllowedTools, []string{"Read"}) {
+		t.Errorf("unexpected meta: %+v", skills[0])
+	}
+}
@@ -0,0 +1,129 @@
+package instill
+
+import (
+	"cmp"
+	"errors"
+	"io/fs"
+	"maps"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// Scope is where a skill is installed.
+type Scope int
+
+const (
+	ScopeProject Scope = iota // an agent's project-level skills directory
+	ScopeGlobal               // an agent's global skills directory
+)
+
+func (s Scope) String() string {
+	if s == ScopeGlobal {
+		return "global"
+	}
+	return "project"
+}
+
+func (s Scope) MarshalText() ([]byte, error) { return []byte(s.String()), nil }
+
+// InstalledSkill is a skill found in an agent's skills directory, whether or
+// not instill installed it.
+type InstalledSkill struct {
+	Name    string // directory name
+	Scope   Scope
+	Path    string    // skill directory
+	Agents  []string  // agents whose skills directory contains the skill
+	Meta    SkillMeta // parsed SKILL.md frontmatter; zero if it cannot be parsed
+	Managed bool      // true if instill installed the skill (.instill.json is present)
+	Owners  []string  // agents recorded in .instill.json
+	Signer  string    // verified signer recorded in .instill.json; see Options.TrustedKeys
+	Link    string    // store directory Path links to (see Options.Symlink); Owners are then the store's
+}
+
+// ListInstalled returns the skills present in the project-level and global
+// skills directories of opts.Agents, or of every registered agent if none are
+// given. Agents sharing a directory are reported together. opts.Global is
+// ignored; both scopes are always listed. Results are sorted by name, scope
+// and path.
+func ListInstalled(opts Options) ([]InstalledSkill, error) {
+	set, err := agentsFor(opts.ProjectDir)
+	if err != nil {
+		return nil, err
+	}
+	if len(opts.Agents) == 0 {
+		opts.Agents = set.names
+	}
+	var out []InstalledSkill
+	agents := opts.Agents
+	for _, scope := range []Scope{ScopeProject, ScopeGlobal} {
+		opts.Global = scope == ScopeGlobal
+		if opts.Global {
+			// Agents from the project's agents file have no global directory.
+			opts.Agents = slices.DeleteFunc(slices.Clone(agents), func(name string) bool {
+				a, ok := set.byName[name]
+				return ok && a.globalDir == ""
+			})
+		}
+		targets, err := resolveTargets(set, opts)
+		if err != nil {
+			return nil, err
+		}
+		for _, dir := range slices.Sorted(maps.Keys(targets)) {
+			if dir == "" {
+				continue // no global directory in this environment
+			}
+			skills, err := installedIn(dir, opts)
+			if err != nil {
+				return nil, err
+			}
+			for i := range skills {
+				skills[i].Scope = scope
+				skills[i].Agents = slices.Sorted(slices.Values(targets[dir]))
+			}
+			out = append(out, skills...)
+		}
+	}
+	slices.SortStableFunc(out, func(a, b InstalledSkill) int {
+		return cmp.Or(strings.Compare(a.Name, b.Name), cmp.Compare(a.Scope, b.Scope), strings.Compare(a.Path, b.Path))
+	})
+	return out, nil
+}
+
+// installedIn lists the skill directories in dir, following links into the
+// store of the scope of opts. Entries that are not directories containing a
+// SKILL.md, and instill's hidden staging and backup directories, are skipped.
+func installedIn(dir string, opts Options) ([]InstalledSkill, error) {
+	fsys := opts.targetFS()
+	entries, err := fsys.ReadDir(dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var out []InstalledSkill
+	for _, e := range entries {
+		if strings.HasPrefix(e.Name(), ".") {
+			continue
+		}
+		skillDir := filepath.Join(dir, e.Name())
+		data, err := fsys.ReadFile(filepath.Join(skillDir, "SKILL.md"))
+		if err != nil {
+			continue // not a skill, or a dangling link
+		}
+		meta, _ := parseFrontmatter(data)
+		s := InstalledSkill{Name: e.Name(), Path: skillDir, Meta: meta}
+		manifestDir := skillDir
+		if store, ok := linkedStore(skillDir, opts); ok {
+			s.Link, manifestDir = store, store
+		}
+		if _, err := fsys.Stat(filepath.Join(manifestDir, manifestName)); err == nil {
+			s.Managed = true
+			m := readManifest(fsys, manifestDir, opts.logger())
+			s.Owners, s.Signer = m.Agents, m.Signer
+		}
+		out = append(out, s)
+	}
+	return out, nil
+}
@@ -0,0 +1,80 @@
+package instill
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestListInstalled(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("CLAUDE_CONFIG_DIR", filepath.Join(home, ".claude"))
+	project := t.TempDir()
+
+	opts := Options{Agents: []string{"claude-code", "cursor", "codex"}, ProjectDir: project}
+	if _, err := Install(skillFSVersioned("my-skill", "1.2.0"), opts); err != nil {
+		t.Fatal(err)
+	}
+
+	// A skill copied by another tool, a stray file and a directory without SKILL.md.
+	manual := filepath.Join(home, ".claude/skills/manual")
+	os.MkdirAll(manual, 0o755)
+	os.WriteFile(filepath.Join(manual, "SKILL.md"), []byte("---\nname: manual\ndescription: by hand\n---\n"), 0o644)
+	os.WriteFile(filepath.Join(home, ".claude/skills/notes.txt"), []byte("x"), 0o644)
+	os.MkdirAll(filepath.Join(home, ".claude/skills/empty"), 0o755)
+
+	got, err := ListInstalled(Options{Agents: opts.Agents, ProjectDir: project})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 3 {
+		t.Fatalf("got %d skills, want 3: %+v", len(got), got)
+	}
+
+	m := got[0]
+	if m.Name != "manual" || m.Scope != ScopeGlobal || m.Managed || m.Meta.Description != "by hand" {
+		t.Errorf("manual = %+v", m)
+	}
+	if !slices.Equal(m.Agents, []string{"claude-code"}) {
+		t.Errorf("manual agents = %v", m.Agents)
+	}
+
+	shared, own := got[1], got[2]
+	if own.Path != filepath.Join(project, ".claude/skills/my-skill") {
+		shared, own = own, shared
+	}
+	if shared.Path != filepath.Join(project, ".agents/skills/my-skill") || !slices.Equal(shared.Agents, []string{"codex", "cursor"}) {
+		t.Errorf("shared = %+v", shared)
+	}
+	if !shared.Managed || !slices.Equal(shared.Owners, []string{"codex", "cursor"}) || shared.Meta.Version != "1.2.0" {
+		t.Errorf("shared = %+v", shared)
+	}
+	if own.Scope != ScopeProject || !slices.Equal(own.Agents, []string{"claude-code"}) {
+		t.Errorf("own = %+v", own)
+	}
+}
+
+func TestListInstalledAllAgents(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("CLAUDE_CONFIG_DIR", filepath.Join(home, ".claude"))
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(home, ".config"))
+	t.Setenv("CODEX_HOME", filepath.Join(home, ".codex"))
+	project := t.TempDir()
+
+	if _, err := Install(skillFS("my-skill"), Options{Agents: []string{"windsurf"}, ProjectDir: project}); err != nil {
+		t.Fatal(err)
+	}
+	got, err := ListInstalled(Options{ProjectDir: project})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 1 || !slices.Equal(got[0].Agents, []string{"windsurf"}) {
+		t.Errorf("got %+v", got)
+	}
+	if _, err := ListInstalled(Options{Agents: []string{"nope"}}); err == nil {
+		t.Error("expected error for unknown agent")
+	}
+}
@@ -1,10 +1,12 @@
 package instill
 
 import (
-	"bytes"
-	"encoding/json"
+	"context"
+	"crypto/ed25519"
+	"errors"
 	"fmt"
 	"io/fs"
+	"log/slog"
 	"maps"
 	"os"
 	"path"
@@ -12,6 +14,7 @@ import (
 	"regexp"
 	"slices"
 	"strings"
+	"time"
 )
 
 // Agent represents a detected AI coding agent
@@ -28,6 +31,57 @@ type Options struct {
 	Skills     []string // if set, only install/update these skill names (by frontmatter name)
 	ProjectDir string   // project root (for project-level operations)
 	Global     bool     // operate on global dirs instead of project-level
+
+	// Conflict decides what Install does with installed files that were
+	// modified or added since instill last wrote them.
+	Conflict ConflictPolicy
+
+	Lock   bool // record installs and removals in the project's LockFile
+	Frozen bool // refuse to install skills whose content does not match the LockFile
+
+	// ContinueOnError makes Install and Remove plan and apply the agents of
+	// each skills directory separately, so that one failing directory does
+	// not stop the others. Every Result is returned, failed ones with Err
+	// set, together with the failures joined into one error.
+	ContinueOnError bool
+
+	// Symlink writes each skill once to the canonical store (see StoreDir)
+	// and links the agents' skill directories to it, copying instead where
+	// links cannot be created.
+	Symlink bool
+
+	// TrustedKeys, if set, makes Install refuse skills that are not signed
+	// by one of these keys; see SignSkills.
+	TrustedKeys []ed25519.PublicKey
+
+	// LockTimeout is how long operations wait for other processes working on
+	// the same directories before failing with ErrLocked. Zero means
+	// DefaultLockTimeout; a negative timeout fails at once.
+	LockTimeout time.Duration
+
+	// Progress, if set, is called with each step of an operation as it
+	// happens, from the calling goroutine; see Event.
+	Progress func(Event)
+
+	// Logger receives a record of every target resolved, environment
+	// variable expanded, manifest read and file written or deleted, and of
+	// errors that do not fail the operation. Nil discards them.
+	Logger *slog.Logger
+
+	// FS is the file system to install into, remove from and list; nil
+	// means the local disk. Sources, agents files and the home directory
+	// are still read from the local disk, and locks are only taken there.
+	FS TargetFS
+}
+
+// discard is the logger of operations without Options.Logger.
+var discard = slog.New(slog.DiscardHandler)
+
+func (o Options) logger() *slog.Logger {
+	if o.Logger != nil {
+		return o.Logger
+	}
+	return discard
 }
 
 // Result reports what happened for each agent
@@ -35,10 +89,14 @@ type Result struct {
 	Agent        string
 	Skill        string
 	Path         string
-	Existed      bool     // true if the skill was already present before this operation
-	PriorVersion string   // version from previously installed SKILL.md ("" if new)
-	Commands     []string // command files installed (e.g., from _commands/)
-	Subagents    []string // subagent files installed (e.g., from _agents/)
+	Existed      bool           // true if the skill was already present before this operation
+	PriorVersion string         // version from previously installed SKILL.md ("" if new)
+	Commands     []string       // command files installed (e.g., from _commands/)
+	Subagents    []string       // subagent files installed (e.g., from _agents/)
+	Owners       []string       // agents that own the skill directory after this operation
+	Link         string         // store directory Path links to, for Options.Symlink installs
+	Conflicts    []FileConflict // locally modified or added files and what happened to them
+	Err          error          // why the operation failed for this agent, under Options.ContinueOnError
 }
 
 type RuntimeAgent struct {
@@ -48,16 +106,21 @@ type RuntimeAgent struct {
 }
 
 // DetectRuntime returns the AI agent currently executing this process, or nil.
+// Custom agents from the working directory's agents file are considered too.
 func DetectRuntime() *RuntimeAgent {
+	set, err := agentsFor(".")
+	if err != nil {
+		set = defaultAgents()
+	}
 	if v := os.Getenv("AI_AGENT"); v != "" {
-		if a, ok := agentIndex[v]; ok {
+		if a, ok := set.byName[v]; ok {
 			return &RuntimeAgent{a.name, a.displayName, "AI_AGENT"}
 		}
 		return &RuntimeAgent{v, v, "AI_AGENT"}
 	}
 
 	if v := os.Getenv("AGENT"); v != "" {
-		if a, ok := agentIndex[v]; ok {
+		if a, ok := set.byName[v]; ok {
 			return &RuntimeAgent{a.name, a.displayName, "AGENT"}
 		}
 	}
@@ -66,8 +129,8 @@ func DetectRuntime() *RuntimeAgent {
 		return &RuntimeAgent{"cowork", "Claude Code (Cowork)", "CLAUDE_CODE_IS_COWORK"}
 	}
 
-	for i := range agents {
-		a := &agents[i]
+	for _, name := range set.names {
+		a := set.byName[name]
 		for _, env := range a.runtimeEnvs {
 			if env == "AGENT" {
 				continue
@@ -87,16 +150,33 @@ func DetectRuntime() *RuntimeAgent {
 
 // Detect returns agents whose config directories exist in projectDir (or globally)
 func Detect(projectDir string, global bool) ([]Agent, error) {
+	return DetectContext(context.Background(), projectDir, global)
+}
+
+// DetectContext is Detect with a context, checked between agents.
+func DetectContext(ctx context.Context, projectDir string, global bool) ([]Agent, error) {
+	return DetectFS(ctx, OSFS{}, projectDir, global)
+}
+
+// DetectFS is DetectContext looking for config directories in fsys.
+func DetectFS(ctx context.Context, fsys TargetFS, projectDir string, global bool) ([]Agent, error) {
+	set, err := agentsFor(projectDir)
+	if err != nil {
+		return nil, err
+	}
 	var out []Agent
-	for i := range agents {
-		a := &agents[i]
+	for _, name := range set.names {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		a := set.byName[name]
 		for _, dd := range a.detectDirs {
-			p := resolvePath(dd, projectDir, global)
+			p := resolvePath(dd, projectDir, global, discard)
 			if p == "" {
 				continue
 			}
-			if _, err := os.Stat(p); err == nil {
-				out = append(out, Agent{a.name, a.displayName, a.skillsDir, resolvePath(a.globalDir, "", true)})
+			if _, err := fsys.Stat(p); err == nil {
+				out = append(out, Agent{a.name, a.displayName, a.skillsDir, resolvePath(a.globalDir, "", true, discard)})
 				break
 			}
 		}
@@ -107,112 +187,134 @@ func Detect(projectDir string, global bool) ([]Agent, error) {
 // Install writes skill files from fsys to each target agent's skills directory.
 // Files under _commands/ and _agents/ in the skill are installed as commands and
 // subagents for agents that support them (e.g., Claude Code).
+// Other processes installing into or removing from the same directories
+// wait for it to finish; see Options.LockTimeout.
 func Install(fsys fs.FS, opts Options) ([]Result, error) {
-	if len(opts.Agents) == 0 {
-		return nil, fmt.Errorf("instill: no agents specified")
+	return InstallContext(context.Background(), fsys, opts)
+}
+
+// InstallContext is Install with a context. If ctx is cancelled before the
+// install completes, nothing is changed and ctx.Err() is returned.
+func InstallContext(ctx context.Context, fsys fs.FS, opts Options) ([]Result, error) {
+	if opts.ContinueOnError {
+		return eachTarget(ctx, opts, func(ctx context.Context, opts Options) (*Plan, error) {
+			return planInstall(ctx, fsys, opts)
+		})
 	}
-	skills, err := findSkills(fsys)
+	unlock, err := lockTargets(ctx, opts)
 	if err != nil {
 		return nil, err
 	}
-	if len(skills) == 0 {
-		return nil, fmt.Errorf("instill: no SKILL.md found in provided filesystem")
-	}
-	targets, err := resolveTargets(opts)
+	defer unlock()
+	p, err := planInstall(ctx, fsys, opts)
 	if err != nil {
 		return nil, err
 	}
-	var results []Result
-	for _, s := range skills {
-		if len(opts.Skills) > 0 && !slices.Contains(opts.Skills, s.name) {
-			continue
-		}
-		for _, dir := range slices.Sorted(maps.Keys(targets)) {
-			agentNames := targets[dir]
-			skillDir := filepath.Join(dir, s.name)
-			_, statErr := os.Stat(skillDir)
-			existed := statErr == nil
-
-			priorVersion := installedVersionAt(skillDir)
-
-			if writeErr := writeFiles(skillDir, s.files); writeErr != nil {
-				return nil, fmt.Errorf("instill: writing to %s: %w", skillDir, writeErr)
-			}
-
-			// Write manifest for removal if skill ships commands or subagents
-			if len(s.commands) > 0 || len(s.subagents) > 0 {
-				writeManifest(skillDir, sortedKeys(s.commands), sortedKeys(s.subagents))
-			}
-
-			for _, an := range agentNames {
-				r := Result{Agent: an, Skill: s.name, Path: skillDir, Existed: existed, PriorVersion: priorVersion}
-
-				if cmds, installErr := installExtras(s.commands, an, commandsDirs, opts); installErr != nil {
-					return nil, installErr
-				} else {
-					r.Commands = cmds
-				}
-
-				if subs, installErr := installExtras(s.subagents, an, subagentsDirs, opts); installErr != nil {
-					return nil, installErr
-				} else {
-					r.Subagents = subs
-				}
-
-				results = append(results, r)
-			}
-		}
-	}
-	return results, nil
+	return p.apply(ctx)
 }
 
 // Remove deletes installed skill files by name, including any commands and
 // subagents that were installed alongside the skill.
 func Remove(skillName string, opts Options) ([]Result, error) {
+	return RemoveContext(context.Background(), skillName, opts)
+}
+
+// RemoveContext is Remove with a context. If ctx is cancelled before the
+// removal completes, nothing is changed and ctx.Err() is returned.
+func RemoveContext(ctx context.Context, skillName string, opts Options) ([]Result, error) {
+	if opts.ContinueOnError {
+		return eachTarget(ctx, opts, func(ctx context.Context, opts Options) (*Plan, error) {
+			return planRemove(ctx, skillName, opts)
+		})
+	}
+	unlock, err := lockTargets(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+	p, err := planRemove(ctx, skillName, opts)
+	if err != nil {
+		return nil, err
+	}
+	return p.apply(ctx)
+}
+
+// eachTarget runs an operation under Options.ContinueOnError: planned and
+// applied in a transaction of its own for the agents of each skills
+// directory, in directory order. Unknown agents fail on their own; the
+// failures of a directory are reported as a *TargetError.
+func eachTarget(ctx context.Context, opts Options, plan func(context.Context, Options) (*Plan, error)) ([]Result, error) {
 	if len(opts.Agents) == 0 {
 		return nil, fmt.Errorf("instill: no agents specified")
 	}
-	if skillName == "" {
-		return nil, fmt.Errorf("instill: skill name required")
-	}
-	skillName = sanitizeName(skillName)
-	targets, err := resolveTargets(opts)
+	set, err := agentsFor(opts.ProjectDir)
 	if err != nil {
 		return nil, err
 	}
 	var results []Result
+	var errs []error
+	known := opts
+	known.Agents = nil
+	for _, name := range opts.Agents {
+		if _, ok := set.byName[name]; !ok {
+			err := &UnknownAgentError{name}
+			opts.logger().Error("unknown agent", "agent", name)
+			results = append(results, Result{Agent: name, Err: err})
+			errs = append(errs, err)
+			continue
+		}
+		known.Agents = append(known.Agents, name)
+	}
+	targets, err := resolveTargets(set, known)
+	if err != nil {
+		return nil, err
+	}
+	unlock, err := lockTargets(ctx, known)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
 	for _, dir := range slices.Sorted(maps.Keys(targets)) {
-		agentNames := targets[dir]
-		skillDir := filepath.Join(dir, skillName)
-		_, statErr := os.Stat(skillDir)
-		existed := statErr == nil
-
-		// Read manifest before deleting the skill directory
-		m := readManifest(skillDir)
-
-		if existed {
-			if rmErr := os.RemoveAll(skillDir); rmErr != nil {
-				return nil, fmt.Errorf("instill: removing %s: %w", skillDir, rmErr)
+		one := known
+		one.Agents = targets[dir]
+		p, err := plan(ctx, one)
+		var rs []Result
+		if err == nil {
+			rs, err = p.apply(ctx)
+		}
+		if err == nil {
+			results = append(results, rs...)
+			continue
+		}
+		terr := &TargetError{one.Agents, dir, err}
+		errs = append(errs, terr)
+		if p == nil {
+			for _, an := range one.Agents {
+				results = append(results, Result{Agent: an, Path: dir, Err: terr})
 			}
+			continue
 		}
-		for _, an := range agentNames {
-			removeExtras(m.Commands, an, commandsDirs, opts)
-			removeExtras(m.Subagents, an, subagentsDirs, opts)
-			results = append(results, Result{Agent: an, Skill: skillName, Path: skillDir, Existed: existed})
+		for _, r := range p.Results {
+			r.Err = terr
+			results = append(results, r)
 		}
 	}
-	return results, nil
+	return results, errors.Join(errs...)
 }
 
 // InstalledVersion returns the version from an installed skill's SKILL.md frontmatter.
 // Returns "" if the skill is not installed or has no version field.
 func InstalledVersion(skillName string, opts Options) (string, error) {
-	targets, err := resolveTargets(opts)
+	set, err := agentsFor(opts.ProjectDir)
+	if err != nil {
+		return "", err
+	}
+	targets, err := resolveTargets(set, opts)
 	if err != nil {
 		return "", err
 	}
 	for _, dir := range slices.Sorted(maps.Keys(targets)) {
-		if v := installedVersionAt(filepath.Join(dir, skillName)); v != "" {
+		if v := installedVersionAt(opts.targetFS(), filepath.Join(dir, skillName)); v != "" {
 			return v, nil
 		}
 	}
@@ -220,10 +322,16 @@ func InstalledVersion(skillName string, opts Options) (string, error) {
 }
 
 // SkillMeta holds metadata parsed from a SKILL.md frontmatter.
+// See https://agentskills.io/specification for the meaning of each field.
 type SkillMeta struct {
-	Name        string
-	Version     string
-	Description string
+	Name          string
+	Version       string // top-level version, or metadata.version
+	Description   string
+	License       string
+	Compatibility string
+	AllowedTools  []string          // pre-approved tools, from a space-delimited string or a list
+	Metadata      map[string]string // arbitrary key-value metadata
+	Extra         map[string]any    // fields not defined by the specification
 }
 
 // ListSkills returns metadata for every skill found in fsys.
@@ -237,13 +345,12 @@ func ListSkills(fsys fs.FS) []SkillMeta {
 		if err != nil {
 			return err
 		}
-		name, _ := parseFrontmatterField(data, "name")
-		if name == "" {
+		meta, _ := parseFrontmatter(data)
+		if meta.Name == "" {
 			return fs.SkipDir
 		}
-		version, _ := parseFrontmatterField(data, "version")
-		desc, _ := parseFrontmatterField(data, "description")
-		out = append(out, SkillMeta{Name: sanitizeName(name), Version: version, Description: desc})
+		meta.Name = sanitizeName(meta.Name)
+		out = append(out, meta)
 		return fs.SkipDir
 	})
 	return out
@@ -258,38 +365,49 @@ func SkillVersion(fsys fs.FS) string {
 	return ""
 }
 
-func installedVersionAt(skillDir string) string {
-	data, err := os.ReadFile(filepath.Join(skillDir, "SKILL.md"))
+func installedVersionAt(fsys TargetFS, skillDir string) string {
+	data, err := fsys.ReadFile(filepath.Join(skillDir, "SKILL.md"))
 	if err != nil {
 		return ""
 	}
-	v, _ := parseFrontmatterField(data, "version")
-	return v
+	meta, _ := parseFrontmatter(data)
+	return meta.Version
 }
 
-func resolveTargets(opts Options) (map[string][]string, error) {
+func resolveTargets(set *agentSet, opts Options) (map[string][]string, error) {
+	log := opts.logger()
 	targets := map[string][]string{}
 	for _, name := range opts.Agents {
-		a, ok := agentIndex[name]
+		a, ok := set.byName[name]
 		if !ok {
-			return nil, fmt.Errorf("instill: unknown agent %q", name)
+			log.Error("unknown agent", "agent", name)
+			return nil, &UnknownAgentError{name}
 		}
-		var dir string
-		if opts.Global {
-			dir = resolvePath(a.globalDir, "", true)
-		} else {
-			dir = filepath.Join(opts.ProjectDir, a.skillsDir)
+		if opts.Global && a.globalDir == "" {
+			return nil, fmt.Errorf("instill: agent %q is defined by the project's %s and has no global skills directory", name, AgentsFile)
 		}
+		dir := skillsDirOf(a, opts)
+		log.Debug("resolved target", "agent", name, "dir", dir, "global", opts.Global)
 		targets[dir] = append(targets[dir], name)
 	}
 	return targets, nil
 }
 
+// skillsDirOf returns the skills directory of a in the scope of opts.
+func skillsDirOf(a *agent, opts Options) string {
+	if opts.Global {
+		return resolvePath(a.globalDir, "", true, opts.logger())
+	}
+	return filepath.Join(opts.ProjectDir, a.skillsDir)
+}
+
 type skillEntry struct {
 	name      string
+	dir       string            // directory of SKILL.md in the source
 	files     map[string][]byte // regular skill files
 	commands  map[string][]byte // files from _commands/ (filename → content)
 	subagents map[string][]byte // files from _agents/ (filename → content)
+	signature []byte            // content of SignatureFile, if any
 }
 
 // skipDirs are directories excluded from regular skill file collection.
@@ -308,7 +426,7 @@ func findSkills(fsys fs.FS) ([]skillEntry, error) {
 		}
 		name, err := parseName(data)
 		if err != nil {
-			return fmt.Errorf("%s: %w", p, err)
+			return &InvalidSkillError{p, err}
 		}
 		skillDir := path.Dir(p)
 		files := map[string][]byte{}
@@ -341,7 +459,8 @@ func findSkills(fsys fs.FS) ([]skillEntry, error) {
 		}
 		commands := collectSubdir(fsys, skillDir, "_commands")
 		subagents := collectSubdir(fsys, skillDir, "_agents")
-		out = append(out, skillEntry{name, files, commands, subagents})
+		sig, _ := fs.ReadFile(fsys, path.Join(skillDir, SignatureFile))
+		out = append(out, skillEntry{name, skillDir, files, commands, subagents, sig})
 		return fs.SkipDir
 	})
 	return out, err
@@ -390,140 +509,22 @@ func sanitizeName(name string) string {
 }
 
 func parseName(data []byte) (string, error) {
-	name, err := parseFrontmatterField(data, "name")
+	meta, err := parseFrontmatter(data)
 	if err != nil {
 		return "", err
 	}
-	if name == "" {
+	if meta.Name == "" {
 		return "", fmt.Errorf("frontmatter missing required 'name' field")
 	}
-	return sanitizeName(name), nil
-}
-
-func parseFrontmatterField(data []byte, field string) (string, error) {
-	data = bytes.TrimSpace(data)
-	if !bytes.HasPrefix(data, []byte("---")) {
-		return "", fmt.Errorf("missing frontmatter (must start with ---)")
-	}
-	parts := bytes.SplitN(data, []byte("---"), 3)
-	if len(parts) < 3 {
-		return "", fmt.Errorf("malformed frontmatter: missing closing ---")
-	}
-	for _, line := range bytes.Split(parts[1], []byte("\n")) {
-		k, v, ok := bytes.Cut(line, []byte(":"))
-		if ok && strings.TrimSpace(string(k)) == field {
-			val := strings.TrimSpace(string(v))
-			val = strings.Trim(val, `"'`)
-			return val, nil
-		}
-	}
-	return "", nil
+	return sanitizeName(meta.Name), nil
 }
 
-var excludedFiles = map[string]bool{"README.md": true, "metadata.json": true}
+var excludedFiles = map[string]bool{"README.md": true, "metadata.json": true, SignatureFile: true}
 
 func isExcluded(name string) bool {
 	return excludedFiles[name] || strings.HasPrefix(name, "_")
 }
 
-func writeFiles(dir string, files map[string][]byte) error {
-	// Clean stale files from previous installs
-	if err := os.RemoveAll(dir); err != nil {
-		return err
-	}
-	for rel, content := range files {
-		target := filepath.Join(dir, rel)
-		if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
-			return err
-		}
-		if err := os.WriteFile(target, content, 0o644); err != nil {
-			return err
-		}
-	}
-	return nil
-}
-
-// installExtras writes command or subagent files to the appropriate directory
-// for agents that support them. Returns the list of installed filenames.
-func installExtras(files map[string][]byte, agentName string, dirs map[string][2]string, opts Options) ([]string, error) {
-	if len(files) == 0 {
-		return nil, nil
-	}
-	d, ok := dirs[agentName]
-	if !ok {
-		return nil, nil
-	}
-	var targetDir string
-	if opts.Global {
-		targetDir = resolvePath(d[1], "", true)
-	} else {
-		targetDir = filepath.Join(opts.ProjectDir, d[0])
-	}
-	if targetDir == "" {
-		return nil, nil
-	}
-	if err := os.MkdirAll(targetDir, 0o755); err != nil {
-		return nil, fmt.Errorf("instill: creating %s: %w", targetDir, err)
-	}
-	var installed []string
-	for name, content := range files {
-		target := filepath.Join(targetDir, name)
-		if err := os.WriteFile(target, content, 0o644); err != nil {
-			return nil, fmt.Errorf("instill: writing %s: %w", target, err)
-		}
-		installed = append(installed, name)
-	}
-	slices.Sort(installed)
-	return installed, nil
-}
-
-// removeExtras deletes command or subagent files for agents that support them.
-func removeExtras(files []string, agentName string, dirs map[string][2]string, opts Options) {
-	if len(files) == 0 {
-		return
-	}
-	d, ok := dirs[agentName]
-	if !ok {
-		return
-	}
-	var targetDir string
-	if opts.Global {
-		targetDir = resolvePath(d[1], "", true)
-	} else {
-		targetDir = filepath.Join(opts.ProjectDir, d[0])
-	}
-	if targetDir == "" {
-		return
-	}
-	for _, name := range files {
-		_ = os.Remove(filepath.Join(targetDir, name))
-	}
-}
-
-type extrasManifest struct {
-	Commands  []string `json:"commands,omitempty"`
-	Subagents []string `json:"subagents,omitempty"`
-}
-
-func writeManifest(skillDir string, commands, subagents []string) {
-	m := extrasManifest{Commands: commands, Subagents: subagents}
-	data, err := json.Marshal(m)
-	if err != nil {
-		return
-	}
-	_ = os.WriteFile(filepath.Join(skillDir, ".instill.json"), data, 0o644)
-}
-
-func readManifest(skillDir string) extrasManifest {
-	data, err := os.ReadFile(filepath.Join(skillDir, ".instill.json"))
-	if err != nil {
-		return extrasManifest{}
-	}
-	var m extrasManifest
-	_ = json.Unmarshal(data, &m)
-	return m
-}
-
 func sortedKeys(m map[string][]byte) []string {
 	keys := make([]string, 0, len(m))
 	for k := range m {
@@ -533,8 +534,8 @@ func sortedKeys(m map[string][]byte) []string {
 	return keys
 }
 
-func resolvePath(path, projectDir string, global bool) string {
-	path = expandEnv(path)
+func resolvePath(path, projectDir string, global bool, log *slog.Logger) string {
+	path = expandEnv(path, log)
 	if strings.HasPrefix(path, "~") {
 		home, _ := os.UserHomeDir()
 		if path == "~" {
@@ -557,15 +558,20 @@ var envDefaults = map[string]string{
 	"CODEX_HOME":        "~/.codex",
 }
 
-func expandEnv(path string) string {
+func expandEnv(path string, log *slog.Logger) string {
 	for k, def := range envDefaults {
 		ph := "$" + k
 		if strings.Contains(path, ph) {
 			v := strings.TrimSpace(os.Getenv(k))
-			if v == "" {
-				home, _ := os.UserHomeDir()
+			unset := v == ""
+			if unset {
+				home, err := os.UserHomeDir()
+				if err != nil {
+					log.Warn("home directory unknown", "var", k, "err", err)
+				}
 				v = strings.Replace(def, "~", home, 1)
 			}
+			log.Debug("expanded environment variable", "var", k, "value", v, "default", unset)
 			path = strings.ReplaceAll(path, ph, v)
 		}
 	}
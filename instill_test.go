@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
 	"testing"
 	"testing/fstest"
 )
@@ -328,6 +329,19 @@ func TestInstall(t *testing.T) {
 	}
 }
 
+func TestInstallNonStringFields(t *testing.T) {
+	tmp := t.TempDir()
+	fsys := fstest.MapFS{"x/SKILL.md": &fstest.MapFile{Data: []byte("---\nname: x\ncompatibility: [claude-code, cursor]\nmetadata:\n  version: \"1.2\"\n  tags: [a, b]\n  owner:\n    team: docs\n---\n")}}
+	opts := Options{Agents: []string{"claude-code"}, ProjectDir: tmp}
+	if _, err := Install(fsys, opts); err != nil {
+		t.Fatal(err)
+	}
+	installed, err := ListInstalled(opts)
+	if err != nil || len(installed) != 1 || installed[0].Name != "x" || installed[0].Meta.Version != "1.2" {
+		t.Errorf("ListInstalled = %+v, %v", installed, err)
+	}
+}
+
 func TestInstallOverwrite(t *testing.T) {
 	tmp := t.TempDir()
 	if err := os.MkdirAll(filepath.Join(tmp, ".claude/skills/x"), 0o755); err != nil {
@@ -667,3 +681,43 @@ func TestRoundTrip(t *testing.T) {
 		}
 	}
 }
+
+func TestRemoveSharedDirKeepsOtherOwners(t *testing.T) {
+	tmp := t.TempDir()
+	dir := filepath.Join(tmp, ".agents/skills/x")
+	if _, err := Install(skillFS("x"), Options{Agents: []string{"codex", "cursor"}, ProjectDir: tmp}); err != nil {
+		t.Fatal(err)
+	}
+	results, err := Install(skillFS("x"), Options{Agents: []string{"gemini-cli"}, ProjectDir: tmp})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := results[0].Owners; !slices.Equal(got, []string{"codex", "cursor", "gemini-cli"}) {
+		t.Errorf("Owners after install = %v", got)
+	}
+
+	results, err = Remove("x", Options{Agents: []string{"cursor"}, ProjectDir: tmp})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := results[0].Owners; !slices.Equal(got, []string{"codex", "gemini-cli"}) {
+		t.Errorf("remaining Owners = %v", got)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "SKILL.md")); err != nil {
+		t.Fatal("skill should remain for other owners")
+	}
+	if got := readManifest(OSFS{}, dir, discard).Agents; !slices.Equal(got, []string{"codex", "gemini-cli"}) {
+		t.Errorf("manifest owners = %v", got)
+	}
+
+	results, err = Remove("x", Options{Agents: []string{"codex", "gemini-cli"}, ProjectDir: tmp})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 2 || results[0].Owners != nil {
+		t.Errorf("unexpected results: %+v", results)
+	}
+	if _, err := os.Stat(dir); !os.IsNotExist(err) {
+		t.Error("dir should be gone after the last owner is removed")
+	}
+}
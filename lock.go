@@ -0,0 +1,319 @@
+package instill
+
+import (
+	"cmp"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"maps"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// LockFile is the lockfile written to the project root when Options.Lock is
+// set, and read by Verify and when Options.Frozen is set.
+const LockFile = "instill.lock"
+
+// lockVersion is the lockfile format version.
+const lockVersion = 1
+
+// Lock is the content of a lockfile: exactly what was installed, so that
+// every checkout of a project can reproduce and verify it.
+type Lock struct {
+	Version int                    `json:"version"`
+	Skills  map[string]LockedSkill `json:"skills"`
+}
+
+// LockedSkill pins the content of an installed skill.
+type LockedSkill struct {
+	Version string            `json:"version,omitempty"` // from frontmatter
+	Digest  string            `json:"digest"`            // see SkillDigests
+	Files   map[string]string `json:"files"`             // path in the skill source → content hash
+	Targets []LockedTarget    `json:"targets"`           // sorted by agent
+}
+
+// LockedTarget records where a skill was installed for one agent. Paths are
+// slash-separated and relative to the project root.
+type LockedTarget struct {
+	Agent     string   `json:"agent"`
+	Dir       string   `json:"dir"`
+	Commands  []string `json:"commands,omitempty"`
+	Subagents []string `json:"subagents,omitempty"`
+}
+
+// ReadLock reads the LockFile in projectDir. The error wraps fs.ErrNotExist
+// if there is none.
+func ReadLock(projectDir string) (*Lock, error) {
+	return readLock(OSFS{}, projectDir)
+}
+
+func readLock(fsys TargetFS, projectDir string) (*Lock, error) {
+	p := filepath.Join(projectDir, LockFile)
+	data, err := fsys.ReadFile(p)
+	if err != nil {
+		return nil, fmt.Errorf("instill: reading lockfile: %w", err)
+	}
+	var l Lock
+	if err := json.Unmarshal(data, &l); err != nil {
+		return nil, fmt.Errorf("instill: parsing %s: %w", p, err)
+	}
+	if l.Version != lockVersion {
+		return nil, fmt.Errorf("instill: %s has unsupported version %d", p, l.Version)
+	}
+	if l.Skills == nil {
+		l.Skills = map[string]LockedSkill{}
+	}
+	return &l, nil
+}
+
+func (l *Lock) data() []byte {
+	data, _ := json.MarshalIndent(l, "", "  ")
+	return append(data, '\n')
+}
+
+// skillFiles returns every file of a skill keyed by its path in the skill
+// source, commands and subagents included.
+func (s skillEntry) skillFiles() map[string][]byte {
+	all := make(map[string][]byte, len(s.files)+len(s.commands)+len(s.subagents))
+	for rel, data := range s.files {
+		all[rel] = data
+	}
+	for name, data := range s.commands {
+		all["_commands/"+name] = data
+	}
+	for name, data := range s.subagents {
+		all["_agents/"+name] = data
+	}
+	return all
+}
+
+// digest hashes a list of "hash  path" lines, one per file in path order,
+// in the format of sha256sum.
+func digest(hashes map[string]string) string {
+	var b strings.Builder
+	for _, rel := range slices.Sorted(maps.Keys(hashes)) {
+		fmt.Fprintf(&b, "%s  %s\n", strings.TrimPrefix(hashes[rel], "sha256:"), rel)
+	}
+	sum := sha256.Sum256([]byte(b.String()))
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+// SkillDigests returns the content digest of each skill in fsys, keyed by
+// skill name: the SHA-256 of every installed file's path and content,
+// including commands and subagents. It is what LockFile pins.
+func SkillDigests(fsys fs.FS) (map[string]string, error) {
+	skills, err := findSkills(fsys)
+	if err != nil {
+		return nil, err
+	}
+	out := make(map[string]string, len(skills))
+	for _, s := range skills {
+		out[s.name] = digest(hashFiles(s.skillFiles()))
+	}
+	return out, nil
+}
+
+// lockUpdate is a change to the lockfile planned alongside other operations.
+type lockUpdate struct {
+	skill   string
+	agent   string
+	entry   *LockedSkill // nil removes the agent's target
+	target  LockedTarget
+	project string
+}
+
+// checkFrozen returns an error unless every skill's content matches the
+// digest pinned in the project's lockfile.
+func checkFrozen(skills []skillEntry, opts Options) error {
+	l, err := readLock(opts.targetFS(), opts.ProjectDir)
+	if err != nil {
+		return err
+	}
+	for _, s := range skills {
+		if len(opts.Skills) > 0 && !slices.Contains(opts.Skills, s.name) {
+			continue
+		}
+		locked, ok := l.Skills[s.name]
+		if !ok {
+			return fmt.Errorf("instill: skill %q is not pinned in %s", s.name, LockFile)
+		}
+		if d := digest(hashFiles(s.skillFiles())); d != locked.Digest {
+			return fmt.Errorf("instill: skill %q content %s does not match %s pinned in %s", s.name, d, locked.Digest, LockFile)
+		}
+	}
+	return nil
+}
+
+// lockInstall plans recording an installed skill for one agent.
+func (pl *planner) lockInstall(s skillEntry, r Result, opts Options) {
+	files := hashFiles(s.skillFiles())
+	meta, _ := parseFrontmatter(s.files["SKILL.md"])
+	t := LockedTarget{Agent: r.Agent, Dir: projectRel(opts.ProjectDir, r.Path)}
+	if dir := extrasDir(r.Agent, pl.agents.commands, opts); dir != "" {
+		for _, name := range r.Commands {
+			t.Commands = append(t.Commands, projectRel(opts.ProjectDir, filepath.Join(dir, name)))
+		}
+	}
+	if dir := extrasDir(r.Agent, pl.agents.subagents, opts); dir != "" {
+		for _, name := range r.Subagents {
+			t.Subagents = append(t.Subagents, projectRel(opts.ProjectDir, filepath.Join(dir, name)))
+		}
+	}
+	pl.plan.locks = append(pl.plan.locks, lockUpdate{
+		skill: s.name, agent: r.Agent, target: t, project: opts.ProjectDir,
+		entry: &LockedSkill{Version: meta.Version, Digest: digest(files), Files: files},
+	})
+}
+
+func projectRel(projectDir, p string) string {
+	rel, err := filepath.Rel(projectDir, p)
+	if err != nil {
+		return filepath.ToSlash(p)
+	}
+	return filepath.ToSlash(rel)
+}
+
+// lockOp replaces any planned lockfile write with one that applies every
+// planned lockfile change to the lockfile on disk.
+func (p *Plan) lockOp() error {
+	if len(p.locks) == 0 {
+		return nil
+	}
+	target := filepath.Join(p.locks[0].project, LockFile)
+	p.Ops = slices.DeleteFunc(p.Ops, func(op Op) bool { return op.root == "" && op.Path == target })
+
+	l, err := readLock(p.targetFS(), p.locks[0].project)
+	kind := OpOverwrite
+	if errors.Is(err, fs.ErrNotExist) {
+		l, kind = &Lock{Version: lockVersion, Skills: map[string]LockedSkill{}}, OpWrite
+	} else if err != nil {
+		return err
+	}
+	for _, u := range p.locks {
+		if u.project != p.locks[0].project {
+			return fmt.Errorf("instill: cannot combine lockfile changes for %s and %s", p.locks[0].project, u.project)
+		}
+		e, ok := l.Skills[u.skill]
+		if u.entry != nil {
+			targets := e.Targets
+			e = *u.entry
+			e.Targets = targets
+		}
+		e.Targets = slices.DeleteFunc(slices.Clone(e.Targets), func(t LockedTarget) bool { return t.Agent == u.agent })
+		if u.entry != nil {
+			e.Targets = append(e.Targets, u.target)
+			slices.SortFunc(e.Targets, func(a, b LockedTarget) int { return strings.Compare(a.Agent, b.Agent) })
+		}
+		switch {
+		case len(e.Targets) > 0:
+			l.Skills[u.skill] = e
+		case ok:
+			delete(l.Skills, u.skill)
+		}
+	}
+	p.Ops = append(p.Ops, Op{Kind: kind, Path: target, Data: l.data()})
+	return nil
+}
+
+// DriftKind is how an installed file differs from the lockfile.
+type DriftKind int
+
+const (
+	DriftMissing  DriftKind = iota // the file or skill directory does not exist
+	DriftModified                  // the file's content does not match its pinned hash
+	DriftExtra                     // the skill directory contains a file that is not pinned
+)
+
+var driftKindNames = [...]string{"missing", "modified", "extra"}
+
+func (k DriftKind) String() string {
+	if int(k) < len(driftKindNames) {
+		return driftKindNames[k]
+	}
+	return fmt.Sprintf("DriftKind(%d)", int(k))
+}
+
+func (k DriftKind) MarshalText() ([]byte, error) { return []byte(k.String()), nil }
+
+// Drift is a difference between the lockfile and disk.
+type Drift struct {
+	Skill string
+	Agent string
+	Path  string // file or directory on disk
+	Kind  DriftKind
+}
+
+// Verify compares every installed skill recorded in the lockfile of the
+// project in opts.ProjectDir with disk, and returns the differences found,
+// ordered by skill, agent and path. Files instill keeps for itself, the
+// .instill.json manifest and the conflict backups it records, are not
+// reported as extra.
+func Verify(opts Options) ([]Drift, error) {
+	fsys := opts.targetFS()
+	l, err := readLock(fsys, opts.ProjectDir)
+	if err != nil {
+		return nil, err
+	}
+	var out []Drift
+	for _, name := range slices.Sorted(maps.Keys(l.Skills)) {
+		s := l.Skills[name]
+		for _, t := range s.Targets {
+			report := func(p string, kind DriftKind) {
+				out = append(out, Drift{Skill: name, Agent: t.Agent, Path: p, Kind: kind})
+			}
+			dir := filepath.Join(opts.ProjectDir, filepath.FromSlash(t.Dir))
+			expected := map[string]string{}
+			fi, err := fsys.Stat(dir)
+			dirExists := err == nil && fi.IsDir()
+			if !dirExists {
+				report(dir, DriftMissing)
+			}
+			for rel, h := range s.Files {
+				if dirExists && !strings.HasPrefix(rel, "_commands/") && !strings.HasPrefix(rel, "_agents/") {
+					expected[filepath.Join(dir, filepath.FromSlash(rel))] = h
+				}
+			}
+			for _, c := range t.Commands {
+				expected[filepath.Join(opts.ProjectDir, filepath.FromSlash(c))] = s.Files["_commands/"+path.Base(c)]
+			}
+			for _, c := range t.Subagents {
+				expected[filepath.Join(opts.ProjectDir, filepath.FromSlash(c))] = s.Files["_agents/"+path.Base(c)]
+			}
+			for _, p := range slices.Sorted(maps.Keys(expected)) {
+				data, err := fsys.ReadFile(p)
+				switch {
+				case err != nil:
+					report(p, DriftMissing)
+				case hashBytes(data) != expected[p]:
+					report(p, DriftModified)
+				}
+			}
+			// Skills linked to the store are walked through the link.
+			if !dirExists {
+				continue
+			}
+			own := map[string]bool{filepath.Join(dir, manifestName): true}
+			for _, b := range readManifest(fsys, dir, opts.logger()).Backups {
+				own[filepath.Join(dir, filepath.FromSlash(b))] = true
+			}
+			_ = walkDir(fsys, dir, func(p string, d fs.DirEntry, err error) error {
+				if err != nil || d.IsDir() {
+					return nil
+				}
+				if _, ok := expected[p]; !ok && !own[p] {
+					report(p, DriftExtra)
+				}
+				return nil
+			})
+		}
+	}
+	slices.SortStableFunc(out, func(a, b Drift) int {
+		return cmp.Or(strings.Compare(a.Skill, b.Skill), strings.Compare(a.Agent, b.Agent), strings.Compare(a.Path, b.Path))
+	})
+	return out, nil
+}
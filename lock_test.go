@@ -0,0 +1,199 @@
+package instill
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func lockedSkillFS() fstest.MapFS {
+	fsys := skillFSWithRef("my-skill")
+	fsys["skills/my-skill/_commands/run.md"] = &fstest.MapFile{Data: []byte("# Run\n")}
+	return fsys
+}
+
+func TestLockInstallAndRemove(t *testing.T) {
+	project := t.TempDir()
+	opts := Options{Agents: []string{"claude-code", "cursor"}, ProjectDir: project, Lock: true}
+	fsys := lockedSkillFS()
+	if _, err := Install(fsys, opts); err != nil {
+		t.Fatal(err)
+	}
+
+	l, err := ReadLock(project)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, ok := l.Skills["my-skill"]
+	if !ok {
+		t.Fatalf("my-skill not locked: %+v", l)
+	}
+	digests, _ := SkillDigests(fsys)
+	if s.Digest != digests["my-skill"] || !strings.HasPrefix(s.Digest, "sha256:") {
+		t.Errorf("Digest = %q, want %q", s.Digest, digests["my-skill"])
+	}
+	if _, ok := s.Files["_commands/run.md"]; !ok || len(s.Files) != 3 {
+		t.Errorf("Files = %v", s.Files)
+	}
+	want := []LockedTarget{
+		{Agent: "claude-code", Dir: ".claude/skills/my-skill", Commands: []string{".claude/commands/run.md"}},
+		{Agent: "cursor", Dir: ".agents/skills/my-skill"},
+	}
+	if len(s.Targets) != 2 || s.Targets[0].Dir != want[0].Dir || !slices.Equal(s.Targets[0].Commands, want[0].Commands) || s.Targets[1].Dir != want[1].Dir || s.Targets[1].Commands != nil {
+		t.Errorf("Targets = %+v, want %+v", s.Targets, want)
+	}
+
+	// The lockfile is stable across reinstalls.
+	before, _ := os.ReadFile(filepath.Join(project, LockFile))
+	if _, err := Install(fsys, opts); err != nil {
+		t.Fatal(err)
+	}
+	if after, _ := os.ReadFile(filepath.Join(project, LockFile)); string(after) != string(before) {
+		t.Errorf("lockfile changed on reinstall:\n%s\n%s", before, after)
+	}
+
+	if _, err := Remove("my-skill", Options{Agents: []string{"cursor"}, ProjectDir: project, Lock: true}); err != nil {
+		t.Fatal(err)
+	}
+	l, _ = ReadLock(project)
+	if ts := l.Skills["my-skill"].Targets; len(ts) != 1 || ts[0].Agent != "claude-code" {
+		t.Errorf("after removing cursor, targets = %+v", ts)
+	}
+	if _, err := Remove("my-skill", Options{Agents: []string{"claude-code"}, ProjectDir: project, Lock: true}); err != nil {
+		t.Fatal(err)
+	}
+	if l, _ = ReadLock(project); len(l.Skills) != 0 {
+		t.Errorf("lock should be empty: %+v", l.Skills)
+	}
+}
+
+func TestLockCombinedPlans(t *testing.T) {
+	project := t.TempDir()
+	opts := Options{Agents: []string{"claude-code"}, ProjectDir: project, Lock: true}
+	for _, name := range []string{"one", "two"} {
+		if _, err := Install(skillFS(name), opts); err != nil {
+			t.Fatal(err)
+		}
+	}
+	combined := &Plan{}
+	for _, name := range []string{"one", "two"} {
+		p, err := PlanRemove(name, opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := combined.Append(p); err != nil {
+			t.Fatal(err)
+		}
+	}
+	n := 0
+	for _, op := range combined.Ops {
+		if op.Path == filepath.Join(project, LockFile) {
+			n++
+		}
+	}
+	if n != 1 {
+		t.Errorf("combined plan writes the lockfile %d times", n)
+	}
+	if _, err := combined.Apply(); err != nil {
+		t.Fatal(err)
+	}
+	if l, _ := ReadLock(project); len(l.Skills) != 0 {
+		t.Errorf("lock should be empty: %+v", l.Skills)
+	}
+}
+
+func TestVerify(t *testing.T) {
+	project := t.TempDir()
+	opts := Options{Agents: []string{"claude-code"}, ProjectDir: project, Lock: true}
+	if _, err := Install(lockedSkillFS(), opts); err != nil {
+		t.Fatal(err)
+	}
+	drift, err := Verify(Options{ProjectDir: project})
+	if err != nil || len(drift) != 0 {
+		t.Fatalf("fresh install drift = %+v, %v", drift, err)
+	}
+
+	skillDir := filepath.Join(project, ".claude/skills/my-skill")
+	os.WriteFile(filepath.Join(skillDir, "SKILL.md"), []byte("changed"), 0o644)
+	os.WriteFile(filepath.Join(skillDir, "extra.md"), []byte("extra"), 0o644)
+	os.Remove(filepath.Join(project, ".claude/commands/run.md"))
+
+	drift, err = Verify(Options{ProjectDir: project})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, d := range drift {
+		rel, _ := filepath.Rel(project, d.Path)
+		got = append(got, d.Kind.String()+" "+filepath.ToSlash(rel))
+	}
+	want := []string{"missing .claude/commands/run.md", "modified .claude/skills/my-skill/SKILL.md", "extra .claude/skills/my-skill/extra.md"}
+	if !slices.Equal(got, want) {
+		t.Errorf("drift = %v, want %v", got, want)
+	}
+
+	os.RemoveAll(skillDir)
+	if drift, _ = Verify(Options{ProjectDir: project}); len(drift) != 2 || drift[1].Kind != DriftMissing || drift[1].Path != skillDir {
+		t.Errorf("missing skill drift = %+v", drift)
+	}
+
+	if _, err := Verify(Options{ProjectDir: t.TempDir()}); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("no lockfile: err = %v", err)
+	}
+}
+
+func TestFrozen(t *testing.T) {
+	project := t.TempDir()
+	opts := Options{Agents: []string{"claude-code"}, ProjectDir: project}
+
+	frozen := opts
+	frozen.Frozen = true
+	if _, err := Install(skillFS("my-skill"), frozen); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("frozen without lockfile: err = %v", err)
+	}
+
+	locked := opts
+	locked.Lock = true
+	if _, err := Install(skillFS("my-skill"), locked); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Install(skillFS("my-skill"), frozen); err != nil {
+		t.Errorf("matching content refused: %v", err)
+	}
+	if _, err := Install(skillFSVersioned("my-skill", "2.0.0"), frozen); err == nil || !strings.Contains(err.Error(), "does not match") {
+		t.Errorf("changed content: err = %v", err)
+	}
+	if _, err := Install(skillFS("other"), frozen); err == nil || !strings.Contains(err.Error(), "not pinned") {
+		t.Errorf("unpinned skill: err = %v", err)
+	}
+	if v := installedVersionAt(OSFS{}, filepath.Join(project, ".claude/skills/my-skill")); v != "" {
+		t.Errorf("refused install changed disk: version %q", v)
+	}
+
+	locked.Global = true
+	if _, err := Install(skillFS("my-skill"), locked); err == nil {
+		t.Error("expected error for a global lockfile")
+	}
+}
+
+func TestVerifyBackups(t *testing.T) {
+	opts, dir := installWithLocalChanges(t)
+	os.Remove(filepath.Join(dir, "notes.md"))
+	opts.Conflict, opts.Lock = ConflictBackup, true
+	if _, err := Install(skillFSVersioned("x", "2.0"), opts); err != nil {
+		t.Fatal(err)
+	}
+	if drift, err := Verify(opts); err != nil || len(drift) != 0 {
+		t.Errorf("recorded backup reported as drift: %+v, %v", drift, err)
+	}
+	os.WriteFile(filepath.Join(dir, "evil.orig"), []byte("x\n"), 0o644)
+	drift, err := Verify(opts)
+	if err != nil || len(drift) != 1 || drift[0].Path != filepath.Join(dir, "evil.orig") || drift[0].Kind != DriftExtra {
+		t.Errorf("drift = %+v, %v, want evil.orig as extra", drift, err)
+	}
+}
@@ -0,0 +1,105 @@
+package instill
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// testLogger returns a logger recording every level as JSON, and a function
+// decoding the records so far.
+func testLogger(t *testing.T) (*slog.Logger, func() []map[string]any) {
+	var buf bytes.Buffer
+	log := slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	return log, func() []map[string]any {
+		t.Helper()
+		var out []map[string]any
+		for line := range bytes.Lines(buf.Bytes()) {
+			var rec map[string]any
+			if err := json.Unmarshal(line, &rec); err != nil {
+				t.Fatal(err)
+			}
+			out = append(out, rec)
+		}
+		buf.Reset()
+		return out
+	}
+}
+
+// findRecord returns the first record with msg whose attributes include want.
+func findRecord(recs []map[string]any, msg string, want map[string]any) map[string]any {
+	for _, rec := range recs {
+		if rec["msg"] != msg {
+			continue
+		}
+		ok := true
+		for k, v := range want {
+			ok = ok && rec[k] == v
+		}
+		if ok {
+			return rec
+		}
+	}
+	return nil
+}
+
+func TestLogger(t *testing.T) {
+	log, records := testLogger(t)
+	project := t.TempDir()
+	opts := Options{Agents: []string{"claude-code", "cursor"}, ProjectDir: project, Logger: log}
+	if _, err := Install(linkedSkills, opts); err != nil {
+		t.Fatal(err)
+	}
+	recs := records()
+	skillDir := filepath.Join(project, ".claude/skills/alpha")
+	for _, c := range []struct {
+		msg  string
+		want map[string]any
+	}{
+		{"resolved target", map[string]any{"agent": "claude-code", "dir": filepath.Join(project, ".claude/skills"), "level": "DEBUG"}},
+		{"write", map[string]any{"path": filepath.Join(skillDir, "SKILL.md"), "skill": "alpha", "level": "INFO"}},
+		{"write", map[string]any{"path": filepath.Join(skillDir, manifestName), "manifest": true}},
+		{"write", map[string]any{"path": filepath.Join(project, ".claude/commands/run.md"), "agent": "claude-code", "command": true}},
+		{"agent does not support commands", map[string]any{"agent": "cursor"}},
+	} {
+		if findRecord(recs, c.msg, c.want) == nil {
+			t.Errorf("no %q record with %v in %v", c.msg, c.want, recs)
+		}
+	}
+
+	// Errors that do not fail the operation are logged.
+	os.WriteFile(filepath.Join(skillDir, manifestName), []byte("{"), 0o644)
+	os.Remove(filepath.Join(project, ".claude/commands/run.md"))
+	if _, err := Remove("alpha", opts); err != nil {
+		t.Fatal(err)
+	}
+	recs = records()
+	if findRecord(recs, "invalid manifest", map[string]any{"path": filepath.Join(skillDir, manifestName), "level": "WARN"}) == nil {
+		t.Errorf("no warning about the invalid manifest in %v", recs)
+	}
+	if findRecord(recs, "delete", map[string]any{"path": skillDir}) == nil {
+		t.Errorf("no delete record in %v", recs)
+	}
+
+	if _, err := Install(linkedSkills, Options{Agents: []string{"nope"}, ProjectDir: project, Logger: log}); err == nil {
+		t.Fatal("installing for an unknown agent succeeded")
+	}
+	if findRecord(records(), "unknown agent", map[string]any{"agent": "nope", "level": "ERROR"}) == nil {
+		t.Error("unknown agent not logged")
+	}
+}
+
+func TestLoggerExpandEnv(t *testing.T) {
+	log, records := testLogger(t)
+	t.Setenv("CODEX_HOME", "")
+	t.Setenv("HOME", t.TempDir())
+	if got := expandEnv("$CODEX_HOME/skills", log); got != filepath.Join(os.Getenv("HOME"), ".codex/skills") {
+		t.Errorf("expandEnv = %q", got)
+	}
+	if findRecord(records(), "expanded environment variable", map[string]any{"var": "CODEX_HOME", "default": true}) == nil {
+		t.Error("expansion not logged")
+	}
+}
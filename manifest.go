@@ -0,0 +1,82 @@
+package instill
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"log/slog"
+	"path/filepath"
+	"slices"
+)
+
+const manifestName = ".instill.json"
+
+// manifest is written to every installed skill directory as .instill.json.
+type manifest struct {
+	Agents    []string          `json:"agents,omitempty"` // agents the skill was installed for
+	Commands  []string          `json:"commands,omitempty"`
+	Subagents []string          `json:"subagents,omitempty"`
+	Files     map[string]string `json:"files,omitempty"`   // relative path → content hash of what instill wrote
+	Signer    string            `json:"signer,omitempty"`  // key that signed the skill, if verified; see EncodePublicKey
+	Link      string            `json:"link,omitempty"`    // store directory this is a copy of, where a symlink could not be created
+	Backups   []string          `json:"backups,omitempty"` // conflict backups, which belong to the user; see ConflictBackup
+}
+
+func (m manifest) data() []byte {
+	data, _ := json.Marshal(m)
+	return data
+}
+
+// readManifest returns the manifest of skillDir, or an empty one if it has
+// none or it cannot be read.
+func readManifest(fsys TargetFS, skillDir string, log *slog.Logger) manifest {
+	p := filepath.Join(skillDir, manifestName)
+	data, err := fsys.ReadFile(p)
+	if err != nil {
+		if !errors.Is(err, fs.ErrNotExist) {
+			log.Warn("cannot read manifest", "path", p, "err", err)
+		}
+		return manifest{}
+	}
+	var m manifest
+	if err := json.Unmarshal(data, &m); err != nil {
+		log.Warn("invalid manifest", "path", p, "err", err)
+	}
+	log.Debug("read manifest", "path", p, "agents", m.Agents)
+	return m
+}
+
+// hashFiles returns the content hash of every file, keyed by relative path.
+func hashFiles(files map[string][]byte) map[string]string {
+	out := make(map[string]string, len(files))
+	for rel, content := range files {
+		out[rel] = hashBytes(content)
+	}
+	return out
+}
+
+func hashBytes(b []byte) string {
+	sum := sha256.Sum256(b)
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+// mergeKeys returns the union of the keys of a and b.
+func mergeKeys[V1, V2 any](a map[string]V1, b map[string]V2) map[string]bool {
+	out := make(map[string]bool, len(a)+len(b))
+	for k := range a {
+		out[k] = true
+	}
+	for k := range b {
+		out[k] = true
+	}
+	return out
+}
+
+// mergeOwners returns the sorted union of two agent lists.
+func mergeOwners(a, b []string) []string {
+	out := slices.Concat(a, b)
+	slices.Sort(out)
+	return slices.Compact(out)
+}
@@ -0,0 +1,332 @@
+package instill
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// MemFS is a TargetFS held in memory, for previewing an install or building
+// one to ship elsewhere. Paths are cleaned and otherwise taken as they are:
+// "/home/me/.claude" and ".claude" are different directories, and roots such
+// as "/" and "." always exist. It is safe for concurrent use.
+type MemFS struct {
+	mu    sync.Mutex
+	nodes map[string]*memNode
+}
+
+type memNode struct {
+	mode fs.FileMode
+	data []byte
+	link string
+	time time.Time
+}
+
+// NewMemFS returns an empty MemFS.
+func NewMemFS() *MemFS {
+	return &MemFS{nodes: map[string]*memNode{}}
+}
+
+var (
+	errIsDir    = errors.New("is a directory")
+	errNotDir   = errors.New("not a directory")
+	errNotEmpty = errors.New("directory not empty")
+	errNotLink  = errors.New("not a symbolic link")
+	errLoop     = errors.New("too many levels of symbolic links")
+)
+
+func isRoot(p string) bool { return p == "." || filepath.Dir(p) == p }
+
+// resolve returns the path p refers to once symlinks among its parents, and
+// its last element if follow is set, are replaced by their targets.
+func (m *MemFS) resolve(p string, follow bool) (string, error) {
+	hops := 0
+	return m.walkLinks(filepath.Clean(p), follow, &hops)
+}
+
+func (m *MemFS) walkLinks(p string, follow bool, hops *int) (string, error) {
+	for ; ; *hops++ {
+		if *hops > 40 {
+			return "", errLoop
+		}
+		if isRoot(p) {
+			return p, nil
+		}
+		dir, err := m.walkLinks(filepath.Dir(p), true, hops)
+		if err != nil {
+			return "", err
+		}
+		p = filepath.Join(dir, filepath.Base(p))
+		n := m.nodes[p]
+		if !follow || n == nil || n.mode&fs.ModeSymlink == 0 {
+			return p, nil
+		}
+		if p = n.link; !filepath.IsAbs(p) {
+			p = filepath.Join(dir, p)
+		}
+	}
+}
+
+// lookup returns the node at the resolved path p, or nil.
+func (m *MemFS) lookup(p string) *memNode {
+	if isRoot(p) {
+		return &memNode{mode: fs.ModeDir | 0o755}
+	}
+	return m.nodes[p]
+}
+
+// find resolves name and returns its node, failing with op if it does not exist.
+func (m *MemFS) find(op, name string, follow bool) (string, *memNode, error) {
+	if m.nodes == nil {
+		m.nodes = map[string]*memNode{}
+	}
+	p, err := m.resolve(name, follow)
+	if err != nil {
+		return "", nil, &fs.PathError{Op: op, Path: name, Err: err}
+	}
+	n := m.lookup(p)
+	if n == nil {
+		return p, nil, &fs.PathError{Op: op, Path: name, Err: fs.ErrNotExist}
+	}
+	return p, n, nil
+}
+
+// parent checks that the directory to hold the resolved path p exists.
+func (m *MemFS) parent(op, name, p string) error {
+	n := m.lookup(filepath.Dir(p))
+	switch {
+	case n == nil:
+		return &fs.PathError{Op: op, Path: name, Err: fs.ErrNotExist}
+	case !n.mode.IsDir():
+		return &fs.PathError{Op: op, Path: name, Err: errNotDir}
+	}
+	return nil
+}
+
+func (m *MemFS) Stat(name string) (fs.FileInfo, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	p, n, err := m.find("stat", name, true)
+	if err != nil {
+		return nil, err
+	}
+	return nodeInfo{filepath.Base(p), n}, nil
+}
+
+func (m *MemFS) Lstat(name string) (fs.FileInfo, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	p, n, err := m.find("lstat", name, false)
+	if err != nil {
+		return nil, err
+	}
+	return nodeInfo{filepath.Base(p), n}, nil
+}
+
+func (m *MemFS) ReadFile(name string) ([]byte, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	_, n, err := m.find("open", name, true)
+	if err != nil {
+		return nil, err
+	}
+	if n.mode.IsDir() {
+		return nil, &fs.PathError{Op: "read", Path: name, Err: errIsDir}
+	}
+	return slices.Clone(n.data), nil
+}
+
+func (m *MemFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	p, n, err := m.find("open", name, true)
+	if err != nil {
+		return nil, err
+	}
+	if !n.mode.IsDir() {
+		return nil, &fs.PathError{Op: "readdirent", Path: name, Err: errNotDir}
+	}
+	var out []fs.DirEntry
+	for k, c := range m.nodes {
+		if k != p && filepath.Dir(k) == p {
+			out = append(out, fs.FileInfoToDirEntry(nodeInfo{filepath.Base(k), c}))
+		}
+	}
+	slices.SortFunc(out, func(a, b fs.DirEntry) int { return strings.Compare(a.Name(), b.Name()) })
+	return out, nil
+}
+
+func (m *MemFS) Readlink(name string) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	_, n, err := m.find("readlink", name, false)
+	if err != nil {
+		return "", err
+	}
+	if n.mode&fs.ModeSymlink == 0 {
+		return "", &fs.PathError{Op: "readlink", Path: name, Err: errNotLink}
+	}
+	return n.link, nil
+}
+
+func (m *MemFS) MkdirAll(name string, perm fs.FileMode) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.mkdirAll(name, perm)
+}
+
+func (m *MemFS) mkdirAll(name string, perm fs.FileMode) error {
+	p, n, err := m.find("mkdir", name, true)
+	if n != nil {
+		if !n.mode.IsDir() {
+			return &fs.PathError{Op: "mkdir", Path: name, Err: errNotDir}
+		}
+		return nil
+	}
+	if !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	if err := m.mkdirAll(filepath.Dir(p), perm); err != nil {
+		return err
+	}
+	m.nodes[p] = &memNode{mode: fs.ModeDir | perm.Perm(), time: time.Now()}
+	return nil
+}
+
+func (m *MemFS) WriteFile(name string, data []byte, perm fs.FileMode) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	p, n, err := m.find("open", name, true)
+	switch {
+	case n != nil && n.mode.IsDir():
+		return &fs.PathError{Op: "open", Path: name, Err: errIsDir}
+	case n != nil:
+		n.data, n.time = slices.Clone(data), time.Now()
+		return nil
+	case !errors.Is(err, fs.ErrNotExist):
+		return err
+	}
+	if err := m.parent("open", name, p); err != nil {
+		return err
+	}
+	m.nodes[p] = &memNode{mode: perm.Perm(), data: slices.Clone(data), time: time.Now()}
+	return nil
+}
+
+func (m *MemFS) Chmod(name string, mode fs.FileMode) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	p, n, err := m.find("chmod", name, true)
+	if err != nil {
+		return err
+	}
+	if isRoot(p) {
+		return nil
+	}
+	n.mode = n.mode.Type() | mode.Perm()
+	return nil
+}
+
+func (m *MemFS) Symlink(oldname, newname string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	p, n, err := m.find("symlink", newname, false)
+	switch {
+	case n != nil:
+		return &os.LinkError{Op: "symlink", Old: oldname, New: newname, Err: fs.ErrExist}
+	case !errors.Is(err, fs.ErrNotExist):
+		return err
+	}
+	if err := m.parent("symlink", newname, p); err != nil {
+		return err
+	}
+	m.nodes[p] = &memNode{mode: fs.ModeSymlink | 0o777, link: oldname, time: time.Now()}
+	return nil
+}
+
+// Rename moves oldpath, and everything under it, to newpath, replacing a
+// file, symlink or empty directory there.
+func (m *MemFS) Rename(oldpath, newpath string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	linkErr := func(err error) error {
+		return &os.LinkError{Op: "rename", Old: oldpath, New: newpath, Err: err}
+	}
+	from, n, err := m.find("rename", oldpath, false)
+	if err != nil {
+		return linkErr(fs.ErrNotExist)
+	}
+	to, dst, err := m.find("rename", newpath, false)
+	switch {
+	case dst == nil && !errors.Is(err, fs.ErrNotExist):
+		return err
+	case isRoot(from) || isRoot(to) || hasPathPrefix(to, from):
+		return linkErr(fs.ErrInvalid)
+	case dst != nil && dst.mode.IsDir() && !n.mode.IsDir():
+		return linkErr(errIsDir)
+	case dst != nil && !dst.mode.IsDir() && n.mode.IsDir():
+		return linkErr(errNotDir)
+	case dst != nil && dst.mode.IsDir() && m.hasChildren(to):
+		return linkErr(errNotEmpty)
+	}
+	if err := m.parent("rename", newpath, to); err != nil {
+		return err
+	}
+	moved := map[string]*memNode{}
+	for k, c := range m.nodes {
+		if hasPathPrefix(k, from) {
+			moved[to+k[len(from):]] = c
+			delete(m.nodes, k)
+		}
+	}
+	delete(m.nodes, to)
+	for k, c := range moved {
+		m.nodes[k] = c
+	}
+	return nil
+}
+
+func (m *MemFS) hasChildren(p string) bool {
+	for k := range m.nodes {
+		if k != p && hasPathPrefix(k, p) {
+			return true
+		}
+	}
+	return false
+}
+
+// RemoveAll removes name and everything under it. A missing name is not an error.
+func (m *MemFS) RemoveAll(name string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	p, n, err := m.find("removeall", name, false)
+	switch {
+	case n == nil && errors.Is(err, fs.ErrNotExist):
+		return nil
+	case n == nil:
+		return err
+	}
+	for k := range m.nodes {
+		if hasPathPrefix(k, p) || isRoot(p) {
+			delete(m.nodes, k)
+		}
+	}
+	return nil
+}
+
+type nodeInfo struct {
+	name string
+	n    *memNode
+}
+
+func (i nodeInfo) Name() string       { return i.name }
+func (i nodeInfo) Size() int64        { return int64(len(i.n.data)) }
+func (i nodeInfo) Mode() fs.FileMode  { return i.n.mode }
+func (i nodeInfo) ModTime() time.Time { return i.n.time }
+func (i nodeInfo) IsDir() bool        { return i.n.mode.IsDir() }
+func (i nodeInfo) Sys() any           { return nil }
@@ -0,0 +1,571 @@
+package instill
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"maps"
+	"path"
+	"path/filepath"
+	"slices"
+	"time"
+)
+
+// OpKind identifies the kind of filesystem operation in a Plan.
+type OpKind int
+
+const (
+	OpMkdir     OpKind = iota // create a directory and any missing parents
+	OpWrite                   // create a new file
+	OpOverwrite               // replace an existing file
+	OpDelete                  // remove a file or a whole directory tree
+	OpSymlink                 // replace a path with a symlink to a directory
+)
+
+func (k OpKind) String() string {
+	switch k {
+	case OpMkdir:
+		return "mkdir"
+	case OpWrite:
+		return "write"
+	case OpOverwrite:
+		return "overwrite"
+	case OpDelete:
+		return "delete"
+	case OpSymlink:
+		return "symlink"
+	}
+	return fmt.Sprintf("OpKind(%d)", int(k))
+}
+
+func (k OpKind) MarshalText() ([]byte, error) { return []byte(k.String()), nil }
+
+// Op is a single filesystem operation computed by PlanInstall or PlanRemove.
+type Op struct {
+	Kind  OpKind
+	Path  string
+	Skill string // skill the operation belongs to
+	Data  []byte // file content for OpWrite and OpOverwrite; for OpSymlink, the manifest of a fallback copy
+	Link  string // link target for OpSymlink, relative to the link's directory or absolute
+
+	root  string    // skill directory the operation rebuilds, if any
+	agent string    // agent a command or subagent file is for
+	extra EventKind // EventCommand or EventSubagent for their files
+}
+
+// Plan is the full set of operations an Install or Remove would perform.
+// Planning never touches disk; Apply executes exactly the planned operations,
+// atomically: if any of them fails, every target is left as it was.
+type Plan struct {
+	Ops     []Op
+	Results []Result // what Apply reports once the operations succeed
+
+	locks []lockUpdate // planned LockFile changes, written by the last op
+
+	lockDirs    []string      // directories Apply locks; see lockDirsFor
+	lockTimeout time.Duration // how long Apply waits for them
+	progress    func(Event)   // Options.Progress of the planned operation
+	log         *slog.Logger  // Options.Logger of the planned operation
+	fs          TargetFS      // Options.FS of the planned operation
+}
+
+// targetFS returns the file system p applies to.
+func (p *Plan) targetFS() TargetFS {
+	if p.fs != nil {
+		return p.fs
+	}
+	return OSFS{}
+}
+
+// Append adds the operations and results of q to p, for applying several
+// plans in one transaction. Lockfile changes of both plans are combined.
+func (p *Plan) Append(q *Plan) error {
+	p.Ops = append(p.Ops, q.Ops...)
+	p.Results = append(p.Results, q.Results...)
+	p.locks = append(p.locks, q.locks...)
+	p.lockDirs = append(p.lockDirs, q.lockDirs...)
+	p.lockTimeout = max(p.lockTimeout, q.lockTimeout)
+	if p.progress == nil {
+		p.progress = q.progress
+	}
+	if p.log == nil {
+		p.log = q.log
+	}
+	if p.fs == nil {
+		p.fs = q.fs
+	}
+	return p.lockOp()
+}
+
+// PlanInstall computes the operations Install would perform without
+// modifying anything on disk.
+func PlanInstall(fsys fs.FS, opts Options) (*Plan, error) {
+	return planInstall(context.Background(), fsys, opts)
+}
+
+func planInstall(ctx context.Context, fsys fs.FS, opts Options) (*Plan, error) {
+	if len(opts.Agents) == 0 {
+		return nil, fmt.Errorf("instill: no agents specified")
+	}
+	skills, err := findSkills(fsys)
+	if err != nil {
+		return nil, err
+	}
+	if len(skills) == 0 {
+		return nil, fmt.Errorf("instill: no SKILL.md found in provided filesystem")
+	}
+	if opts.Lock && opts.Global {
+		return nil, fmt.Errorf("instill: %s records project-level installs only", LockFile)
+	}
+	if opts.Frozen {
+		if err := checkFrozen(skills, opts); err != nil {
+			return nil, err
+		}
+	}
+	set, err := agentsFor(opts.ProjectDir)
+	if err != nil {
+		return nil, err
+	}
+	targets, err := resolveTargets(set, opts)
+	if err != nil {
+		return nil, err
+	}
+	pl := newPlanner()
+	pl.agents = set
+	if err := pl.prepare(opts); err != nil {
+		return nil, err
+	}
+	pl.resolved(targets)
+	for _, s := range skills {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		if len(opts.Skills) > 0 && !slices.Contains(opts.Skills, s.name) {
+			emit(opts.Progress, Event{Kind: EventSkip, Skill: s.name, Reason: "not selected"})
+			continue
+		}
+		emit(opts.Progress, Event{Kind: EventSkill, Skill: s.name})
+		var signer string
+		if len(opts.TrustedKeys) > 0 {
+			if signer, err = checkSignature(s, opts.TrustedKeys); err != nil {
+				return nil, err
+			}
+		}
+		if opts.Symlink {
+			if err := pl.linkSkill(s, targets, signer, opts); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		for _, dir := range slices.Sorted(maps.Keys(targets)) {
+			if err := pl.copySkill(s, filepath.Join(dir, s.name), targets[dir], signer, opts); err != nil {
+				return nil, err
+			}
+		}
+	}
+	pl.releaseStores()
+	if err := pl.plan.lockOp(); err != nil {
+		return nil, err
+	}
+	return &pl.plan, nil
+}
+
+// copySkill plans writing a full copy of s to skillDir for agentNames.
+func (pl *planner) copySkill(s skillEntry, skillDir string, agentNames []string, signer string, opts Options) error {
+	_, statErr := pl.fs.Stat(skillDir)
+	existed := statErr == nil
+
+	priorVersion := installedVersionAt(pl.fs, skillDir)
+
+	var prev manifest
+	if store, ok := linkedStore(skillDir, opts); ok {
+		// Replace a link to the store with a copy the agents own alone.
+		pl.release(store, agentNames)
+	} else if existed {
+		prev = readManifest(pl.fs, skillDir, pl.log)
+	}
+	files := maps.Clone(s.files)
+	conflicts, keep, backups, err := resolveConflicts(pl.fs, skillDir, prev, files, opts.Conflict)
+	if err != nil {
+		return err
+	}
+
+	// The manifest records which agents own the directory, what to
+	// clean up on removal and what instill wrote.
+	owners := mergeOwners(prev.Agents, agentNames)
+	files[manifestName] = manifest{
+		Agents:    owners,
+		Commands:  sortedKeys(s.commands),
+		Subagents: sortedKeys(s.subagents),
+		Files:     hashFiles(s.files),
+		Signer:    signer,
+		Backups:   backups,
+	}.data()
+	pl.files(skillDir, s.name, files, keep)
+	pl.kept(skillDir, s.name, conflicts)
+
+	for _, an := range agentNames {
+		r := Result{Agent: an, Skill: s.name, Path: skillDir, Existed: existed, PriorVersion: priorVersion, Owners: owners, Conflicts: conflicts}
+		pl.installed(s, r, opts)
+	}
+	return nil
+}
+
+// installed plans the commands and subagents of s for r.Agent and records r.
+func (pl *planner) installed(s skillEntry, r Result, opts Options) {
+	r.Commands = pl.extras(s.commands, s.name, r.Agent, EventCommand, pl.agents.commands, opts)
+	r.Subagents = pl.extras(s.subagents, s.name, r.Agent, EventSubagent, pl.agents.subagents, opts)
+	pl.plan.Results = append(pl.plan.Results, r)
+	if opts.Lock {
+		pl.lockInstall(s, r, opts)
+	}
+}
+
+// PlanRemove computes the operations Remove would perform without
+// modifying anything on disk.
+func PlanRemove(skillName string, opts Options) (*Plan, error) {
+	return planRemove(context.Background(), skillName, opts)
+}
+
+func planRemove(ctx context.Context, skillName string, opts Options) (*Plan, error) {
+	if len(opts.Agents) == 0 {
+		return nil, fmt.Errorf("instill: no agents specified")
+	}
+	if skillName == "" {
+		return nil, fmt.Errorf("instill: skill name required")
+	}
+	skillName = sanitizeName(skillName)
+	if opts.Lock && opts.Global {
+		return nil, fmt.Errorf("instill: %s records project-level installs only", LockFile)
+	}
+	set, err := agentsFor(opts.ProjectDir)
+	if err != nil {
+		return nil, err
+	}
+	targets, err := resolveTargets(set, opts)
+	if err != nil {
+		return nil, err
+	}
+	pl := newPlanner()
+	pl.agents = set
+	if err := pl.prepare(opts); err != nil {
+		return nil, err
+	}
+	pl.resolved(targets)
+	for _, dir := range slices.Sorted(maps.Keys(targets)) {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		agentNames := targets[dir]
+		skillDir := filepath.Join(dir, skillName)
+		_, statErr := pl.fs.Stat(skillDir)
+		existed := statErr == nil
+		if !existed {
+			for _, an := range agentNames {
+				emit(opts.Progress, Event{Kind: EventSkip, Skill: skillName, Agent: an, Path: skillDir, Reason: "not installed"})
+			}
+		}
+
+		// Read manifest before deleting the skill directory
+		m := readManifest(pl.fs, skillDir, pl.log)
+		owners := m.Agents
+
+		// A link to the store, or the store itself, is shared with agents
+		// elsewhere: the store records them all and is released instead.
+		store, linked := linkedStore(skillDir, opts)
+		if linked {
+			owners = readManifest(pl.fs, store, pl.log).Agents
+		} else if skillDir == filepath.Join(storeDir(opts), skillName) {
+			store = skillDir
+		} else {
+			store = ""
+		}
+
+		// Agents sharing the directory that did not ask for removal keep it.
+		var remaining []string
+		for _, owner := range owners {
+			if !slices.Contains(agentNames, owner) && (store == "" || pl.usesDir(owner, dir, opts)) {
+				remaining = append(remaining, owner)
+			}
+		}
+		switch {
+		case store != "":
+			if linked && len(remaining) == 0 {
+				pl.delete(skillDir, skillName)
+			}
+			pl.release(store, agentNames)
+		case existed:
+			pl.root = skillDir
+			if len(remaining) > 0 {
+				m.Agents = remaining
+				pl.write(filepath.Join(skillDir, manifestName), skillName, m.data())
+			} else {
+				pl.delete(skillDir, skillName)
+			}
+			pl.root = ""
+		}
+		for _, an := range agentNames {
+			pl.removeExtras(m.Commands, skillName, an, set.commands, opts)
+			pl.removeExtras(m.Subagents, skillName, an, set.subagents, opts)
+			pl.plan.Results = append(pl.plan.Results, Result{Agent: an, Skill: skillName, Path: skillDir, Existed: existed, Owners: remaining})
+			if opts.Lock {
+				pl.plan.locks = append(pl.plan.locks, lockUpdate{skill: skillName, agent: an, project: opts.ProjectDir})
+			}
+		}
+	}
+	pl.releaseStores()
+	if err := pl.plan.lockOp(); err != nil {
+		return nil, err
+	}
+	return &pl.plan, nil
+}
+
+// Apply executes the planned operations and returns the planned results.
+// Skill directories are rebuilt next to their final location and renamed
+// into place; on failure every already-changed target is restored. Like
+// Install and Remove, Apply waits for other processes working on the same
+// directories, but the plan may be stale if they changed them since it was
+// computed.
+func (p *Plan) Apply() ([]Result, error) {
+	return p.ApplyContext(context.Background())
+}
+
+// ApplyContext is Apply with a context. If ctx is cancelled before the plan
+// is fully applied, every change is rolled back and ctx.Err() is returned.
+func (p *Plan) ApplyContext(ctx context.Context) ([]Result, error) {
+	unlock, err := lockDirs(ctx, p.lockDirs, p.lockTimeout)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+	return p.apply(ctx)
+}
+
+// apply is ApplyContext for callers that already hold the locks.
+func (p *Plan) apply(ctx context.Context) ([]Result, error) {
+	if err := applyOps(ctx, p.targetFS(), p.Ops, p.progress, p.log); err != nil {
+		return nil, err
+	}
+	return p.Results, nil
+}
+
+func applyOp(fsys TargetFS, op Op) error {
+	switch op.Kind {
+	case OpMkdir:
+		return fsys.MkdirAll(op.Path, 0o755)
+	case OpWrite, OpOverwrite:
+		return fsys.WriteFile(op.Path, op.Data, 0o644)
+	case OpDelete:
+		return fsys.RemoveAll(op.Path)
+	case OpSymlink:
+		return fsys.Symlink(op.Link, op.Path)
+	}
+	return fmt.Errorf("unknown operation %v", op.Kind)
+}
+
+// prepare records what applying a plan with opts needs: the directories to
+// lock, how long to wait for them, and where to report progress and log.
+func (pl *planner) prepare(opts Options) error {
+	dirs, err := lockDirsFor(pl.agents, opts)
+	if err != nil {
+		return err
+	}
+	pl.plan.lockDirs, pl.plan.lockTimeout = dirs, lockTimeout(opts)
+	pl.plan.progress = opts.Progress
+	pl.log, pl.plan.log = opts.logger(), opts.logger()
+	pl.fs, pl.plan.fs = opts.targetFS(), opts.FS
+	return nil
+}
+
+// resolved reports the target directories of a plan to its progress callback.
+func (pl *planner) resolved(targets map[string][]string) {
+	for _, dir := range slices.Sorted(maps.Keys(targets)) {
+		for _, an := range targets[dir] {
+			emit(pl.plan.progress, Event{Kind: EventTarget, Agent: an, Path: dir})
+		}
+	}
+}
+
+// kept reports the files of skillDir that keep their local changes as skipped.
+func (pl *planner) kept(skillDir, skill string, conflicts []FileConflict) {
+	for _, c := range conflicts {
+		if c.Outcome != FileOverwritten {
+			emit(pl.plan.progress, Event{Kind: EventSkip, Skill: skill, Path: filepath.Join(skillDir, filepath.FromSlash(c.File)), Reason: "local changes kept"})
+		}
+	}
+}
+
+// planner accumulates operations while tracking the state the disk will be
+// in once the operations planned so far have been applied.
+type planner struct {
+	plan    Plan
+	agents  *agentSet
+	root    string          // skill directory being planned, if any
+	agent   string          // agent the command or subagent files being planned are for
+	extra   EventKind       // EventCommand or EventSubagent while planning their files
+	dirs    map[string]bool // directories planned for creation
+	written map[string]bool // files planned for writing
+	deleted map[string]bool // paths planned for deletion
+
+	released map[string][]string // store directory → agents that no longer use it
+	log      *slog.Logger
+	fs       TargetFS
+}
+
+func newPlanner() *planner {
+	return &planner{dirs: map[string]bool{}, written: map[string]bool{}, deleted: map[string]bool{}, log: discard, fs: OSFS{}}
+}
+
+func (pl *planner) add(kind OpKind, p, skill string, data []byte) {
+	pl.plan.Ops = append(pl.plan.Ops, Op{Kind: kind, Path: p, Skill: skill, Data: data, root: pl.root, agent: pl.agent, extra: pl.extra})
+}
+
+// exists reports whether p will exist once the planned operations are applied.
+func (pl *planner) exists(p string) (fs.FileInfo, bool) {
+	for d := p; ; d = filepath.Dir(d) {
+		if pl.deleted[d] {
+			return nil, false
+		}
+		if filepath.Dir(d) == d {
+			break
+		}
+	}
+	fi, err := pl.fs.Lstat(p)
+	return fi, err == nil
+}
+
+func (pl *planner) mkdirAll(dir, skill string) {
+	if pl.dirs[dir] {
+		return
+	}
+	if fi, ok := pl.exists(dir); ok && fi.IsDir() {
+		pl.dirs[dir] = true
+		return
+	}
+	pl.add(OpMkdir, dir, skill, nil)
+	for d := dir; !pl.dirs[d]; d = filepath.Dir(d) {
+		pl.dirs[d] = true
+		if filepath.Dir(d) == d {
+			break
+		}
+	}
+}
+
+func (pl *planner) write(target, skill string, data []byte) {
+	if pl.written[target] {
+		return
+	}
+	pl.written[target] = true
+	kind := OpWrite
+	if _, ok := pl.exists(target); ok {
+		kind = OpOverwrite
+	}
+	pl.add(kind, target, skill, data)
+}
+
+func (pl *planner) delete(target, skill string) {
+	pl.add(OpDelete, target, skill, nil)
+	pl.deleted[target] = true
+}
+
+// files plans replacing the contents of dir with files, deleting anything
+// left over from a previous install except the paths in keep.
+func (pl *planner) files(dir, skill string, files map[string][]byte, keep map[string]bool) {
+	pl.root = dir
+	defer func() { pl.root = "" }()
+	if fi, ok := pl.exists(dir); ok && !fi.IsDir() {
+		pl.delete(dir, skill)
+	}
+	keepDirs := map[string]bool{}
+	for rel := range mergeKeys(files, keep) {
+		for d := path.Dir(rel); d != "."; d = path.Dir(d) {
+			keepDirs[d] = true
+		}
+	}
+	pl.stale(dir, "", skill, files, keep, keepDirs)
+	for _, rel := range sortedKeys(files) {
+		if keep[rel] {
+			continue
+		}
+		target := filepath.Join(dir, filepath.FromSlash(rel))
+		pl.mkdirAll(filepath.Dir(target), skill)
+		pl.write(target, skill, files[rel])
+	}
+}
+
+// stale plans deletion of entries under dir/rel that the new install does not contain.
+func (pl *planner) stale(dir, rel, skill string, files map[string][]byte, keep, keepDirs map[string]bool) {
+	entries, err := pl.fs.ReadDir(filepath.Join(dir, filepath.FromSlash(rel)))
+	if err != nil {
+		return
+	}
+	for _, e := range entries {
+		r := path.Join(rel, e.Name())
+		_, isFile := files[r]
+		switch {
+		case e.IsDir() && keepDirs[r]:
+			pl.stale(dir, r, skill, files, keep, keepDirs)
+		case e.Type().IsRegular() && (isFile || keep[r]):
+			// overwritten in place or kept
+		default:
+			pl.delete(filepath.Join(dir, filepath.FromSlash(r)), skill)
+		}
+	}
+}
+
+// extras plans writing command or subagent files to the appropriate directory
+// for agents that support them. Returns the list of planned filenames.
+func (pl *planner) extras(files map[string][]byte, skill, agentName string, kind EventKind, dirs map[string][2]string, opts Options) []string {
+	if len(files) == 0 {
+		return nil
+	}
+	targetDir := extrasDir(agentName, dirs, opts)
+	if targetDir == "" {
+		pl.log.Debug("agent does not support "+kind.String()+"s", "agent", agentName, "skill", skill, "files", sortedKeys(files))
+		return nil
+	}
+	pl.mkdirAll(targetDir, skill)
+	pl.agent, pl.extra = agentName, kind
+	defer func() { pl.agent, pl.extra = "", 0 }()
+	names := sortedKeys(files)
+	for _, name := range names {
+		pl.write(filepath.Join(targetDir, name), skill, files[name])
+	}
+	return names
+}
+
+// removeExtras plans deletion of command or subagent files for agents that support them.
+func (pl *planner) removeExtras(files []string, skill, agentName string, dirs map[string][2]string, opts Options) {
+	targetDir := extrasDir(agentName, dirs, opts)
+	if targetDir == "" {
+		return
+	}
+	for _, name := range files {
+		target := filepath.Join(targetDir, name)
+		if _, ok := pl.exists(target); !ok {
+			pl.log.Debug("already removed", "path", target, "agent", agentName, "skill", skill)
+			continue
+		}
+		pl.delete(target, skill)
+	}
+}
+
+// usesDir reports whether the skills directory of the named agent is dir.
+func (pl *planner) usesDir(name, dir string, opts Options) bool {
+	a, ok := pl.agents.byName[name]
+	return ok && skillsDirOf(a, opts) == dir
+}
+
+// extrasDir returns the command or subagent directory for agentName, or "" if
+// the agent has none.
+func extrasDir(agentName string, dirs map[string][2]string, opts Options) string {
+	d, ok := dirs[agentName]
+	if !ok {
+		return ""
+	}
+	if opts.Global {
+		return resolvePath(d[1], "", true, opts.logger())
+	}
+	return filepath.Join(opts.ProjectDir, d[0])
+}
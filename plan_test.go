@@ -0,0 +1,144 @@
+package instill
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+)
+
+func opsByPath(p *Plan) map[string]OpKind {
+	m := map[string]OpKind{}
+	for _, op := range p.Ops {
+		m[op.Path] = op.Kind
+	}
+	return m
+}
+
+func TestPlanInstallDoesNotTouchDisk(t *testing.T) {
+	tmp := t.TempDir()
+	fsys := skillFSWithRef("my-tool")
+	fsys["skills/my-tool/_commands/run.md"] = &fstest.MapFile{Data: []byte("# run")}
+
+	p, err := PlanInstall(fsys, Options{Agents: []string{"claude-code"}, ProjectDir: tmp})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if entries, _ := os.ReadDir(tmp); len(entries) != 0 {
+		t.Fatalf("planning wrote to disk: %v", entries)
+	}
+
+	skillDir := filepath.Join(tmp, ".claude/skills/my-tool")
+	ops := opsByPath(p)
+	for path, want := range map[string]OpKind{
+		skillDir:                                          OpMkdir,
+		filepath.Join(skillDir, "SKILL.md"):               OpWrite,
+		filepath.Join(skillDir, "references"):             OpMkdir,
+		filepath.Join(skillDir, "references/commands.md"): OpWrite,
+		filepath.Join(skillDir, manifestName):             OpWrite,
+		filepath.Join(tmp, ".claude/commands"):            OpMkdir,
+		filepath.Join(tmp, ".claude/commands/run.md"):     OpWrite,
+	} {
+		if got, ok := ops[path]; !ok || got != want {
+			t.Errorf("%s: got %v (planned=%v), want %v", path, got, ok, want)
+		}
+	}
+	if len(p.Results) != 1 || p.Results[0].Commands[0] != "run.md" {
+		t.Fatalf("unexpected results: %+v", p.Results)
+	}
+
+	results, err := p.Apply()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 1 {
+		t.Fatalf("unexpected results: %+v", results)
+	}
+	for _, op := range p.Ops {
+		if _, err := os.Stat(op.Path); err != nil {
+			t.Errorf("%s %s not applied: %v", op.Kind, op.Path, err)
+		}
+	}
+}
+
+func TestPlanInstallOverwriteAndStale(t *testing.T) {
+	tmp := t.TempDir()
+	dir := filepath.Join(tmp, ".claude/skills/x")
+	if err := os.MkdirAll(filepath.Join(dir, "old"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	for _, f := range []string{"SKILL.md", "stale.md", "old/ref.md"} {
+		if err := os.WriteFile(filepath.Join(dir, f), []byte("old"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	p, err := PlanInstall(skillFS("x"), Options{Agents: []string{"claude-code"}, ProjectDir: tmp})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ops := opsByPath(p)
+	if ops[filepath.Join(dir, "SKILL.md")] != OpOverwrite {
+		t.Error("expected SKILL.md to be overwritten")
+	}
+	if ops[filepath.Join(dir, "stale.md")] != OpDelete || ops[filepath.Join(dir, "old")] != OpDelete {
+		t.Errorf("expected stale entries to be deleted, got %v", ops)
+	}
+	if _, ok := ops[dir]; ok {
+		t.Error("existing skill dir should not be recreated")
+	}
+
+	if _, err := p.Apply(); err != nil {
+		t.Fatal(err)
+	}
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 2 || entries[0].Name() != manifestName || entries[1].Name() != "SKILL.md" {
+		t.Errorf("unexpected contents after apply: %v", entries)
+	}
+}
+
+func TestPlanRemove(t *testing.T) {
+	tmp := t.TempDir()
+	fsys := skillFS("x")
+	fsys["_agents/helper.md"] = &fstest.MapFile{Data: []byte("# helper")}
+	opts := Options{Agents: []string{"claude-code"}, ProjectDir: tmp}
+	if _, err := Install(fsys, opts); err != nil {
+		t.Fatal(err)
+	}
+
+	p, err := PlanRemove("x", opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{filepath.Join(tmp, ".claude/skills/x"), filepath.Join(tmp, ".claude/agents/helper.md")}
+	if len(p.Ops) != len(want) {
+		t.Fatalf("got %d ops, want %d: %+v", len(p.Ops), len(want), p.Ops)
+	}
+	for i, op := range p.Ops {
+		if op.Kind != OpDelete || op.Path != want[i] {
+			t.Errorf("op %d = %v %s, want delete %s", i, op.Kind, op.Path, want[i])
+		}
+	}
+	if _, err := os.Stat(want[1]); err != nil {
+		t.Fatal("planning should not delete anything")
+	}
+
+	if _, err := p.Apply(); err != nil {
+		t.Fatal(err)
+	}
+	for _, w := range want {
+		if _, err := os.Stat(w); !os.IsNotExist(err) {
+			t.Errorf("%s should be gone", w)
+		}
+	}
+}
+
+func TestPlanRemoveNotInstalled(t *testing.T) {
+	p, err := PlanRemove("x", Options{Agents: []string{"claude-code"}, ProjectDir: t.TempDir()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(p.Ops) != 0 {
+		t.Errorf("expected no ops, got %+v", p.Ops)
+	}
+}
@@ -0,0 +1,308 @@
+package instill
+
+import (
+	"bytes"
+	"cmp"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// AgentDef defines a custom agent, or overrides fields of a registered one.
+// Directories use the same syntax as the built-in registry: project paths are
+// relative to the project root, global paths start with ~, an environment
+// variable such as $XDG_CONFIG_HOME, or are absolute.
+type AgentDef struct {
+	Name               string   `json:"name"`
+	DisplayName        string   `json:"displayName,omitempty"`
+	SkillsDir          string   `json:"skillsDir,omitempty"`
+	GlobalSkillsDir    string   `json:"globalSkillsDir,omitempty"`
+	CommandsDir        string   `json:"commandsDir,omitempty"`
+	GlobalCommandsDir  string   `json:"globalCommandsDir,omitempty"`
+	SubagentsDir       string   `json:"subagentsDir,omitempty"`
+	GlobalSubagentsDir string   `json:"globalSubagentsDir,omitempty"`
+	DetectDirs         []string `json:"detectDirs,omitempty"`
+	RuntimeEnvs        []string `json:"runtimeEnvs,omitempty"`
+	Hooks              bool     `json:"hooks,omitempty"`
+	MCP                bool     `json:"mcp,omitempty"`
+}
+
+// AgentsEnv names an environment variable pointing to an agents file that
+// every operation loads on top of the registered agents.
+const AgentsEnv = "INSTILL_AGENTS"
+
+// AgentsFile is the agents file that operations on a project load from its
+// root, on top of the registered agents and the file named by AgentsEnv.
+// Since it comes with the project, it may only add agents, with directories
+// inside the project: it cannot override an agent or name global
+// directories, and its agents cannot be used with Options.Global.
+const AgentsFile = ".instill-agents.json"
+
+// agentsFileData is the format of an agents file.
+type agentsFileData struct {
+	Agents []AgentDef `json:"agents"`
+}
+
+// RegisterAgent adds a custom agent, or replaces the non-empty fields of an
+// already registered one. Registered agents are used by every operation in the
+// process.
+func RegisterAgent(def AgentDef) error {
+	registry.Lock()
+	defer registry.Unlock()
+	set := registry.set.clone()
+	if err := set.add(def, false); err != nil {
+		return fmt.Errorf("instill: %w", err)
+	}
+	registry.set = set
+	return nil
+}
+
+// LoadAgents registers every agent defined in an agents file, a JSON object
+// of the form {"agents": [AgentDef...]}. Either all definitions are
+// registered or none are.
+func LoadAgents(path string) error {
+	defs, err := readAgentsFile(path)
+	if err != nil {
+		return err
+	}
+	registry.Lock()
+	defer registry.Unlock()
+	set := registry.set.clone()
+	for _, def := range defs {
+		if err := set.add(def, false); err != nil {
+			return fmt.Errorf("instill: %s: %w", path, err)
+		}
+	}
+	registry.set = set
+	return nil
+}
+
+// agentSet is an immutable snapshot of the agent registry.
+type agentSet struct {
+	byName    map[string]*agent
+	names     []string             // sorted
+	commands  map[string][2]string // [project, global] command directories
+	subagents map[string][2]string // [project, global] subagent directories
+}
+
+var registry = struct {
+	sync.RWMutex
+	set *agentSet
+}{set: builtinAgents()}
+
+func builtinAgents() *agentSet {
+	s := &agentSet{
+		byName:    make(map[string]*agent, len(agents)),
+		commands:  maps.Clone(commandsDirs),
+		subagents: maps.Clone(subagentsDirs),
+	}
+	for i := range agents {
+		a := agents[i]
+		s.byName[a.name] = &a
+		s.names = append(s.names, a.name)
+	}
+	slices.Sort(s.names)
+	return s
+}
+
+func (s *agentSet) clone() *agentSet {
+	return &agentSet{
+		byName:    maps.Clone(s.byName),
+		names:     slices.Clone(s.names),
+		commands:  maps.Clone(s.commands),
+		subagents: maps.Clone(s.subagents),
+	}
+}
+
+// add applies def to the set. Agents are replaced, never modified in place,
+// because older snapshots share them. Definitions from a project's AgentsFile
+// are restricted to new agents with project directories; see AgentsFile.
+func (s *agentSet) add(def AgentDef, project bool) error {
+	if !validName.MatchString(def.Name) {
+		return fmt.Errorf("invalid agent name %q", def.Name)
+	}
+	a := &agent{name: def.Name, displayName: def.Name}
+	old, exists := s.byName[def.Name]
+	if project {
+		if err := checkProjectDef(def, exists); err != nil {
+			return err
+		}
+	}
+	if exists {
+		c := *old
+		a = &c
+	}
+	a.displayName = cmp.Or(def.DisplayName, a.displayName)
+	a.skillsDir = cmp.Or(def.SkillsDir, a.skillsDir)
+	a.globalDir = cmp.Or(def.GlobalSkillsDir, a.globalDir)
+	if def.DetectDirs != nil {
+		a.detectDirs = slices.Clone(def.DetectDirs)
+	}
+	if def.RuntimeEnvs != nil {
+		a.runtimeEnvs = slices.Clone(def.RuntimeEnvs)
+	}
+	if def.Hooks {
+		a.caps |= CapHooks
+	}
+	if def.MCP {
+		a.caps |= CapMCP
+	}
+	if a.skillsDir == "" || a.globalDir == "" && !project {
+		return fmt.Errorf("agent %q needs skillsDir and globalSkillsDir", def.Name)
+	}
+
+	commands, err := mergeDirs(def.Name, "commands", s.commands[def.Name], def.CommandsDir, def.GlobalCommandsDir, project)
+	if err != nil {
+		return err
+	}
+	subagents, err := mergeDirs(def.Name, "subagents", s.subagents[def.Name], def.SubagentsDir, def.GlobalSubagentsDir, project)
+	if err != nil {
+		return err
+	}
+	for _, p := range [...]string{a.skillsDir, commands[0], subagents[0]} {
+		if err := checkProjectDir(def.Name, p); err != nil {
+			return err
+		}
+	}
+	for _, p := range [...]string{a.globalDir, commands[1], subagents[1]} {
+		if err := checkGlobalDir(def.Name, p); err != nil {
+			return err
+		}
+	}
+
+	s.byName[a.name] = a
+	if !exists {
+		i, _ := slices.BinarySearch(s.names, a.name)
+		s.names = slices.Insert(s.names, i, a.name)
+	}
+	if commands[0] != "" {
+		s.commands[a.name] = commands
+	}
+	if subagents[0] != "" {
+		s.subagents[a.name] = subagents
+	}
+	return nil
+}
+
+// mergeDirs overrides a [project, global] directory pair; a pair must be
+// either fully set or empty, unless it is for the project only.
+func mergeDirs(name, kind string, dirs [2]string, project, global string, projectOnly bool) ([2]string, error) {
+	dirs = [2]string{cmp.Or(project, dirs[0]), cmp.Or(global, dirs[1])}
+	if (dirs[0] == "") != (dirs[1] == "") && !(projectOnly && dirs[1] == "") {
+		return dirs, fmt.Errorf("agent %q needs both project and global %s directories", name, kind)
+	}
+	return dirs, nil
+}
+
+func checkProjectDir(name, p string) error {
+	if p == "" {
+		return nil
+	}
+	if filepath.IsAbs(p) || strings.HasPrefix(p, "~") || strings.HasPrefix(p, "$") || !filepath.IsLocal(filepath.FromSlash(p)) {
+		return fmt.Errorf("agent %q: project directory %q must be relative to the project root", name, p)
+	}
+	// Skills are removed by deleting <dir>/<name>, so the project root itself
+	// would expose every top-level directory of the project.
+	if filepath.Clean(filepath.FromSlash(p)) == "." {
+		return fmt.Errorf("agent %q: project directory %q must be below the project root", name, p)
+	}
+	return nil
+}
+
+// checkProjectDef rejects what a project's AgentsFile may not do: override an
+// agent defined outside the project, or name directories outside it, which a
+// cloned repository could otherwise use to make installs and removals write
+// or delete anywhere.
+func checkProjectDef(def AgentDef, exists bool) error {
+	if exists {
+		return fmt.Errorf("agent %q is already defined; %s can only add agents (use %s or RegisterAgent to override one)", def.Name, AgentsFile, AgentsEnv)
+	}
+	if def.GlobalSkillsDir != "" || def.GlobalCommandsDir != "" || def.GlobalSubagentsDir != "" {
+		return fmt.Errorf("agent %q: %s cannot set global directories (use %s or RegisterAgent)", def.Name, AgentsFile, AgentsEnv)
+	}
+	for _, p := range def.DetectDirs {
+		if err := checkProjectDir(def.Name, p); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func checkGlobalDir(name, p string) error {
+	if p == "" || strings.HasPrefix(p, "~") || strings.HasPrefix(p, "$") || filepath.IsAbs(p) {
+		return nil
+	}
+	return fmt.Errorf("agent %q: global directory %q must be absolute or start with ~ or $", name, p)
+}
+
+func readAgentsFile(path string) ([]AgentDef, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("instill: reading agents file: %w", err)
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	var f agentsFileData
+	if err := dec.Decode(&f); err != nil {
+		return nil, fmt.Errorf("instill: parsing %s: %w", path, err)
+	}
+	return f.Agents, nil
+}
+
+// agentsFor returns the registered agents overlaid with the file named by
+// AgentsEnv and, if projectDir is set, the project's AgentsFile.
+func agentsFor(projectDir string) (*agentSet, error) {
+	registry.RLock()
+	set := registry.set
+	registry.RUnlock()
+
+	var files []string
+	if p := os.Getenv(AgentsEnv); p != "" {
+		files = append(files, p)
+	}
+	project := ""
+	if projectDir != "" {
+		p := filepath.Join(projectDir, AgentsFile)
+		if _, err := os.Stat(p); err == nil {
+			files, project = append(files, p), p
+		} else if !errors.Is(err, fs.ErrNotExist) {
+			return nil, fmt.Errorf("instill: reading agents file: %w", err)
+		}
+	}
+	if len(files) == 0 {
+		return set, nil
+	}
+	set = set.clone()
+	for _, p := range files {
+		defs, err := readAgentsFile(p)
+		if err != nil {
+			return nil, err
+		}
+		for _, def := range defs {
+			if err := set.add(def, p == project); err != nil {
+				return nil, fmt.Errorf("instill: %s: %w", p, err)
+			}
+		}
+	}
+	return set, nil
+}
+
+// defaultAgents is agentsFor without a project, for APIs that cannot report
+// errors; a broken AgentsEnv file is ignored there and reported by the
+// operations that return errors.
+func defaultAgents() *agentSet {
+	set, err := agentsFor("")
+	if err != nil {
+		registry.RLock()
+		defer registry.RUnlock()
+		return registry.set
+	}
+	return set
+}
@@ -0,0 +1,220 @@
+package instill
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+// resetAgents restores the registry when the test ends.
+func resetAgents(t *testing.T) {
+	t.Helper()
+	registry.RLock()
+	saved := registry.set
+	registry.RUnlock()
+	t.Cleanup(func() {
+		registry.Lock()
+		registry.set = saved
+		registry.Unlock()
+	})
+	t.Setenv(AgentsEnv, "")
+}
+
+func TestRegisterAgent(t *testing.T) {
+	resetAgents(t)
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	err := RegisterAgent(AgentDef{
+		Name:              "acme",
+		DisplayName:       "Acme Agent",
+		SkillsDir:         ".acme/skills",
+		GlobalSkillsDir:   "~/.acme/skills",
+		CommandsDir:       ".acme/commands",
+		GlobalCommandsDir: "~/.acme/commands",
+		DetectDirs:        []string{".acme"},
+		RuntimeEnvs:       []string{"ACME_AGENT"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Contains(AgentNames(), "acme") {
+		t.Error("AgentNames should include acme")
+	}
+	info, ok := LookupAgent("acme")
+	if !ok || info.DisplayName != "Acme Agent" || !info.Capabilities.Has(CapCommands) || info.Capabilities.Has(CapSubagents) {
+		t.Errorf("LookupAgent(acme) = %+v, %v", info, ok)
+	}
+
+	project := t.TempDir()
+	os.MkdirAll(filepath.Join(project, ".acme"), 0o755)
+	detected, err := Detect(project, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(detected) != 1 || detected[0].Name != "acme" || detected[0].GlobalDir != filepath.Join(home, ".acme/skills") {
+		t.Errorf("Detect = %+v", detected)
+	}
+
+	fsys := skillFS("my-skill")
+	fsys["_commands/go.md"] = &fstest.MapFile{Data: []byte("# Go\n")}
+	results, err := Install(fsys, Options{Agents: []string{"acme"}, ProjectDir: project})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if results[0].Path != filepath.Join(project, ".acme/skills/my-skill") || len(results[0].Commands) != 1 {
+		t.Errorf("results = %+v", results)
+	}
+	if _, err := os.Stat(filepath.Join(project, ".acme/commands/go.md")); err != nil {
+		t.Errorf("command not installed: %v", err)
+	}
+	if _, err := Remove("my-skill", Options{Agents: []string{"acme"}, ProjectDir: project}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(project, ".acme/commands/go.md")); !os.IsNotExist(err) {
+		t.Error("command should be removed")
+	}
+
+	clearRuntimeEnvs(t)
+	t.Setenv("ACME_AGENT", "1")
+	if got := DetectRuntime(); got == nil || got.Name != "acme" || got.EnvVar != "ACME_AGENT" {
+		t.Errorf("DetectRuntime = %+v", got)
+	}
+}
+
+func TestRegisterAgentOverride(t *testing.T) {
+	resetAgents(t)
+	if err := RegisterAgent(AgentDef{Name: "claude-code", SkillsDir: ".claude-fork/skills"}); err != nil {
+		t.Fatal(err)
+	}
+	info, _ := LookupAgent("claude-code")
+	if info.SkillsDir.Raw != ".claude-fork/skills" || info.GlobalSkillsDir.Raw != "$CLAUDE_CONFIG_DIR/skills" || info.DisplayName != "Claude Code" {
+		t.Errorf("override should only replace set fields: %+v", info)
+	}
+	if !info.Capabilities.Has(CapCommands | CapSubagents) {
+		t.Errorf("override lost capabilities: %v", info.Capabilities)
+	}
+	if n := len(AgentNames()); n != len(agents) {
+		t.Errorf("override should not add an agent, got %d names", n)
+	}
+	// Built-in data is untouched.
+	if builtinAgents().byName["claude-code"].skillsDir != ".claude/skills" {
+		t.Error("built-in agent was modified")
+	}
+}
+
+func TestRegisterAgentInvalid(t *testing.T) {
+	resetAgents(t)
+	tests := []struct {
+		name string
+		def  AgentDef
+		want string
+	}{
+		{"bad name", AgentDef{Name: "Acme/1", SkillsDir: ".a", GlobalSkillsDir: "~/.a"}, "invalid agent name"},
+		{"missing dirs", AgentDef{Name: "acme", SkillsDir: ".a"}, "needs skillsDir and globalSkillsDir"},
+		{"absolute project dir", AgentDef{Name: "acme", SkillsDir: "/a", GlobalSkillsDir: "~/.a"}, "must be relative"},
+		{"escaping project dir", AgentDef{Name: "acme", SkillsDir: "../a", GlobalSkillsDir: "~/.a"}, "must be relative"},
+		{"relative global dir", AgentDef{Name: "acme", SkillsDir: ".a", GlobalSkillsDir: ".a"}, "must be absolute"},
+		{"half commands pair", AgentDef{Name: "acme", SkillsDir: ".a", GlobalSkillsDir: "~/.a", CommandsDir: ".a/c"}, "both project and global commands"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := RegisterAgent(tt.def)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("err = %v, want %q", err, tt.want)
+			}
+		})
+	}
+	if _, ok := LookupAgent("acme"); ok {
+		t.Error("invalid definitions must not be registered")
+	}
+}
+
+func TestAgentsFiles(t *testing.T) {
+	resetAgents(t)
+	dir := t.TempDir()
+	envFile := filepath.Join(dir, "agents.json")
+	os.WriteFile(envFile, []byte(`{"agents": [
+		{"name": "acme", "skillsDir": ".acme/skills", "globalSkillsDir": "/opt/acme/skills", "detectDirs": [".acme"]}
+	]}`), 0o644)
+	t.Setenv(AgentsEnv, envFile)
+
+	if _, ok := LookupAgent("acme"); !ok {
+		t.Fatalf("agent from %s not found", AgentsEnv)
+	}
+
+	// The project file adds agents with project directories.
+	project := t.TempDir()
+	os.WriteFile(filepath.Join(project, AgentsFile), []byte(`{"agents": [{"name": "tool", "skillsDir": "tools/skills", "commandsDir": "tools/commands"}]}`), 0o644)
+	results, err := Install(skillFS("my-skill"), Options{Agents: []string{"tool", "acme"}, ProjectDir: project})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := filepath.Join(project, "tools/skills/my-skill"); results[1].Path != want {
+		t.Errorf("Path = %q, want %q", results[1].Path, want)
+	}
+	if _, err := os.Stat(filepath.Join(results[1].Path, "SKILL.md")); err != nil {
+		t.Error(err)
+	}
+	if installed, err := ListInstalled(Options{ProjectDir: project}); err != nil || len(installed) != 2 {
+		t.Errorf("ListInstalled = %+v, %v", installed, err)
+	}
+	if _, err := Install(skillFS("my-skill"), Options{Agents: []string{"tool"}, ProjectDir: project, Global: true}); err == nil || !strings.Contains(err.Error(), "no global skills directory") {
+		t.Errorf("global install for a project agent: %v", err)
+	}
+
+	// Project files apply only to their project.
+	if _, ok := LookupAgent("tool"); ok {
+		t.Error("project file leaked into the registry")
+	}
+
+	// They cannot redirect agents defined elsewhere, reach outside the project
+	// or use its root.
+	for _, def := range []string{
+		`{"name": "acme", "skillsDir": "tools/acme/skills"}`,
+		`{"name": "claude-code", "globalSkillsDir": "/tmp/elsewhere"}`,
+		`{"name": "tool", "skillsDir": "tools/skills", "globalSkillsDir": "~/.tool/skills"}`,
+		`{"name": "tool", "skillsDir": "tools/skills", "commandsDir": "tools/commands", "globalCommandsDir": "$HOME/commands"}`,
+		`{"name": "tool", "skillsDir": "../outside/skills"}`,
+		`{"name": "tool", "skillsDir": "."}`,
+		`{"name": "tool", "skillsDir": "tools/.."}`,
+		`{"name": "tool", "skillsDir": "tools/skills", "subagentsDir": "./"}`,
+		`{"name": "tool", "skillsDir": "tools/skills", "detectDirs": ["~/.tool"]}`,
+	} {
+		os.WriteFile(filepath.Join(project, AgentsFile), []byte(`{"agents": [`+def+`]}`), 0o644)
+		if _, err := Install(skillFS("my-skill"), Options{Agents: []string{"claude-code"}, ProjectDir: project}); err == nil {
+			t.Errorf("%s: accepted", def)
+		}
+	}
+
+	os.WriteFile(filepath.Join(project, AgentsFile), []byte(`{"agents": [{"name": "acme", "skilsDir": "x"}]}`), 0o644)
+	if _, err := Detect(project, false); err == nil || !strings.Contains(err.Error(), "skilsDir") {
+		t.Errorf("expected unknown field error, got %v", err)
+	}
+}
+
+func TestLoadAgents(t *testing.T) {
+	resetAgents(t)
+	file := filepath.Join(t.TempDir(), "agents.json")
+	os.WriteFile(file, []byte(`{"agents": [
+		{"name": "one", "skillsDir": ".one/skills", "globalSkillsDir": "~/.one/skills"},
+		{"name": "two", "skillsDir": ".two/skills"}
+	]}`), 0o644)
+	if err := LoadAgents(file); err == nil || !strings.Contains(err.Error(), `"two"`) {
+		t.Errorf("expected error for agent two, got %v", err)
+	}
+	if _, ok := LookupAgent("one"); ok {
+		t.Error("a failed load must not register any agent")
+	}
+
+	os.WriteFile(file, []byte(`{"agents": [{"name": "one", "skillsDir": ".one/skills", "globalSkillsDir": "~/.one/skills"}]}`), 0o644)
+	if err := LoadAgents(file); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := LookupAgent("one"); !ok {
+		t.Error("agent one not registered")
+	}
+}
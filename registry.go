@@ -0,0 +1,124 @@
+package instill
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Capability is a set of extension kinds an agent supports.
+type Capability uint8
+
+const (
+	CapSkills    Capability = 1 << iota // Agent Skills (every registered agent)
+	CapCommands                         // slash-command files, installed from _commands/
+	CapSubagents                        // subagent definitions, installed from _agents/
+	CapHooks                            // user-defined lifecycle hooks
+	CapMCP                              // MCP servers
+)
+
+var capabilityNames = []struct {
+	c    Capability
+	name string
+}{
+	{CapSkills, "skills"},
+	{CapCommands, "commands"},
+	{CapSubagents, "subagents"},
+	{CapHooks, "hooks"},
+	{CapMCP, "mcp"},
+}
+
+// Has reports whether c includes every capability in x.
+func (c Capability) Has(x Capability) bool { return c&x == x }
+
+func (c Capability) String() string {
+	var names []string
+	for _, n := range capabilityNames {
+		if c.Has(n.c) {
+			names = append(names, n.name)
+		}
+	}
+	if rest := c &^ (CapSkills | CapCommands | CapSubagents | CapHooks | CapMCP); rest != 0 {
+		names = append(names, fmt.Sprintf("0x%x", uint8(rest)))
+	}
+	return strings.Join(names, ",")
+}
+
+func (c Capability) MarshalText() ([]byte, error) { return []byte(c.String()), nil }
+
+// AgentPath is a directory as declared in the registry and as resolved for
+// the current environment.
+type AgentPath struct {
+	Raw      string // as declared, e.g. "$CLAUDE_CONFIG_DIR/skills" or ".claude/skills"
+	Resolved string // ~ and environment variables expanded; project paths stay relative
+}
+
+// AgentInfo describes a registered agent: where it looks for skills and other
+// extensions, how it is detected, and what it supports.
+type AgentInfo struct {
+	Name         string
+	DisplayName  string
+	Capabilities Capability
+
+	SkillsDir          AgentPath // project-level, relative to the project root
+	GlobalSkillsDir    AgentPath
+	CommandsDir        AgentPath // project-level; empty without CapCommands
+	GlobalCommandsDir  AgentPath
+	SubagentsDir       AgentPath // project-level; empty without CapSubagents
+	GlobalSubagentsDir AgentPath
+
+	DetectDirs  []AgentPath // directories whose presence marks the agent as installed
+	RuntimeEnvs []string    // environment variables the agent sets for commands it runs
+}
+
+// Agents returns every registered agent, sorted by name.
+func Agents() []AgentInfo {
+	set := defaultAgents()
+	out := make([]AgentInfo, 0, len(set.names))
+	for _, name := range set.names {
+		out = append(out, set.info(set.byName[name]))
+	}
+	return out
+}
+
+// LookupAgent returns the registered agent with the given name.
+func LookupAgent(name string) (AgentInfo, bool) {
+	set := defaultAgents()
+	a, ok := set.byName[name]
+	if !ok {
+		return AgentInfo{}, false
+	}
+	return set.info(a), true
+}
+
+func (s *agentSet) info(a *agent) AgentInfo {
+	info := AgentInfo{
+		Name:            a.name,
+		DisplayName:     a.displayName,
+		Capabilities:    CapSkills | a.caps,
+		SkillsDir:       projectPath(a.skillsDir),
+		GlobalSkillsDir: globalPath(a.globalDir),
+		RuntimeEnvs:     append([]string(nil), a.runtimeEnvs...),
+	}
+	if d, ok := s.commands[a.name]; ok {
+		info.Capabilities |= CapCommands
+		info.CommandsDir, info.GlobalCommandsDir = projectPath(d[0]), globalPath(d[1])
+	}
+	if d, ok := s.subagents[a.name]; ok {
+		info.Capabilities |= CapSubagents
+		info.SubagentsDir, info.GlobalSubagentsDir = projectPath(d[0]), globalPath(d[1])
+	}
+	for _, dd := range a.detectDirs {
+		p := AgentPath{Raw: dd, Resolved: resolvePath(dd, "", true, discard)}
+		if p.Resolved == "" {
+			p.Resolved = dd // relative to the project root
+		}
+		info.DetectDirs = append(info.DetectDirs, p)
+	}
+	return info
+}
+
+func projectPath(p string) AgentPath { return AgentPath{Raw: p, Resolved: p} }
+
+func globalPath(p string) AgentPath {
+	return AgentPath{Raw: p, Resolved: resolvePath(p, "", true, discard)}
+}
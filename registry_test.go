@@ -0,0 +1,71 @@
+package instill
+
+import (
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestAgents(t *testing.T) {
+	all := Agents()
+	if len(all) != len(agents) {
+		t.Fatalf("got %d agents, want %d", len(all), len(agents))
+	}
+	names := make([]string, len(all))
+	for i, a := range all {
+		names[i] = a.Name
+		if !a.Capabilities.Has(CapSkills) {
+			t.Errorf("%s: missing CapSkills", a.Name)
+		}
+	}
+	if !slices.Equal(names, AgentNames()) {
+		t.Error("Agents() should be sorted like AgentNames()")
+	}
+}
+
+func TestLookupAgent(t *testing.T) {
+	tmp := t.TempDir()
+	t.Setenv("CLAUDE_CONFIG_DIR", tmp)
+
+	a, ok := LookupAgent("claude-code")
+	if !ok {
+		t.Fatal("claude-code not found")
+	}
+	if a.DisplayName != "Claude Code" || a.SkillsDir.Raw != ".claude/skills" {
+		t.Errorf("unexpected info: %+v", a)
+	}
+	if a.GlobalSkillsDir.Raw != "$CLAUDE_CONFIG_DIR/skills" || a.GlobalSkillsDir.Resolved != filepath.Join(tmp, "skills") {
+		t.Errorf("GlobalSkillsDir = %+v", a.GlobalSkillsDir)
+	}
+	if a.GlobalCommandsDir.Resolved != filepath.Join(tmp, "commands") || a.SubagentsDir.Raw != ".claude/agents" {
+		t.Errorf("extras dirs = %+v / %+v", a.GlobalCommandsDir, a.SubagentsDir)
+	}
+	want := CapSkills | CapCommands | CapSubagents | CapHooks | CapMCP
+	if a.Capabilities != want {
+		t.Errorf("Capabilities = %v, want %v", a.Capabilities, want)
+	}
+	if len(a.DetectDirs) != 1 || a.DetectDirs[0].Resolved != tmp {
+		t.Errorf("DetectDirs = %+v", a.DetectDirs)
+	}
+	if !slices.Contains(a.RuntimeEnvs, "CLAUDECODE") {
+		t.Errorf("RuntimeEnvs = %v", a.RuntimeEnvs)
+	}
+
+	b, _ := LookupAgent("codebuddy")
+	if b.Capabilities.Has(CapCommands) || b.CommandsDir.Raw != "" {
+		t.Errorf("codebuddy should not support commands: %+v", b)
+	}
+	if b.DetectDirs[0].Resolved != ".codebuddy" {
+		t.Errorf("project detect dir should stay relative, got %q", b.DetectDirs[0].Resolved)
+	}
+
+	if _, ok := LookupAgent("nope"); ok {
+		t.Error("unexpected agent")
+	}
+}
+
+func TestCapabilityString(t *testing.T) {
+	if got := (CapSkills | CapMCP).String(); got != "skills,mcp" {
+		t.Errorf("String() = %q", got)
+	}
+}
@@ -0,0 +1,89 @@
+package instill
+
+import (
+	"cmp"
+	"strconv"
+	"strings"
+)
+
+// version is a parsed semantic version. Skills often use short versions such
+// as "1.0" or "2", so missing minor and patch numbers count as zero.
+type version struct {
+	core [3]uint64
+	pre  []string // dot-separated pre-release identifiers
+}
+
+// parseVersion parses a semantic version with an optional "v" prefix. Build
+// metadata is ignored, as it does not affect precedence.
+func parseVersion(s string) (version, bool) {
+	var v version
+	s = strings.TrimPrefix(strings.TrimSpace(s), "v")
+	s, _, _ = strings.Cut(s, "+")
+	s, pre, hasPre := strings.Cut(s, "-")
+	parts := strings.Split(s, ".")
+	if len(parts) > 3 {
+		return v, false
+	}
+	for i, p := range parts {
+		n, err := strconv.ParseUint(p, 10, 64)
+		if err != nil || (len(p) > 1 && p[0] == '0') {
+			return v, false
+		}
+		v.core[i] = n
+	}
+	if hasPre {
+		v.pre = strings.Split(pre, ".")
+		for _, id := range v.pre {
+			if id == "" {
+				return v, false
+			}
+		}
+	}
+	return v, true
+}
+
+// compareVersions compares two semantic versions, returning ok=false if
+// either cannot be parsed.
+func compareVersions(a, b string) (c int, ok bool) {
+	va, okA := parseVersion(a)
+	vb, okB := parseVersion(b)
+	if !okA || !okB {
+		return 0, false
+	}
+	for i := range va.core {
+		if c := cmp.Compare(va.core[i], vb.core[i]); c != 0 {
+			return c, true
+		}
+	}
+	// A pre-release has lower precedence than the release itself.
+	switch {
+	case len(va.pre) == 0 && len(vb.pre) == 0:
+		return 0, true
+	case len(va.pre) == 0:
+		return 1, true
+	case len(vb.pre) == 0:
+		return -1, true
+	}
+	for i := 0; i < len(va.pre) && i < len(vb.pre); i++ {
+		if c := comparePrerelease(va.pre[i], vb.pre[i]); c != 0 {
+			return c, true
+		}
+	}
+	return cmp.Compare(len(va.pre), len(vb.pre)), true
+}
+
+// comparePrerelease compares pre-release identifiers: numeric ones
+// numerically and below alphanumeric ones, which compare lexically.
+func comparePrerelease(a, b string) int {
+	na, errA := strconv.ParseUint(a, 10, 64)
+	nb, errB := strconv.ParseUint(b, 10, 64)
+	switch {
+	case errA == nil && errB == nil:
+		return cmp.Compare(na, nb)
+	case errA == nil:
+		return -1
+	case errB == nil:
+		return 1
+	}
+	return strings.Compare(a, b)
+}
@@ -0,0 +1,34 @@
+package instill
+
+import "testing"
+
+func TestCompareVersions(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+		ok   bool
+	}{
+		{"1.0.0", "1.0.0", 0, true},
+		{"1.0", "1.0.0", 0, true},
+		{"v2", "2.0.0", 0, true},
+		{"1.9.0", "1.10.0", -1, true},
+		{"1.2.3", "1.2.2", 1, true},
+		{"1.0.0-alpha", "1.0.0", -1, true},
+		{"1.0.0-alpha", "1.0.0-alpha.1", -1, true},
+		{"1.0.0-alpha.1", "1.0.0-alpha.beta", -1, true},
+		{"1.0.0-beta.2", "1.0.0-beta.11", -1, true},
+		{"1.0.0-rc.1", "1.0.0-beta", 1, true},
+		{"1.0.0+build.5", "1.0.0", 0, true},
+		{"", "1.0.0", 0, false},
+		{"1.0.0", "latest", 0, false},
+		{"1.02.0", "1.2.0", 0, false},
+		{"1.2.3.4", "1.2.3", 0, false},
+		{"1.0.0-", "1.0.0", 0, false},
+	}
+	for _, tt := range tests {
+		got, ok := compareVersions(tt.a, tt.b)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("compareVersions(%q, %q) = %d, %v; want %d, %v", tt.a, tt.b, got, ok, tt.want, tt.ok)
+		}
+	}
+}
@@ -0,0 +1,129 @@
+package instill
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// WellKnownPath is where a SkillsHandler serves skills. Its index is at
+// WellKnownPath + "index.json", in the CatalogIndex format, so a Catalog
+// pointed at it can search, fetch and install them.
+const WellKnownPath = "/.well-known/skills/"
+
+// served is a response body and its ETag.
+type served struct {
+	data []byte
+	etag string
+}
+
+// skillsHandler serves a snapshot of the skills in a file system.
+type skillsHandler struct {
+	files map[string]served // path under WellKnownPath → content
+}
+
+// SkillsHandler returns an http.Handler that publishes the skills in fsys
+// under WellKnownPath:
+//
+//	index.json          the CatalogIndex of every skill
+//	<name>.zip          an archive of the skill, as referenced by the index
+//	<name>/<path>       a raw file of the skill, e.g. my-skill/SKILL.md
+//
+// Skills are served with their SignatureFile, so clients can verify them.
+// Every response carries an ETag derived from its content digest, and
+// conditional requests are answered with 304 Not Modified. fsys is read once,
+// when the handler is created; create a new handler to publish changes.
+// Mount it at WellKnownPath, e.g. http.Handle(instill.WellKnownPath, h).
+func SkillsHandler(fsys fs.FS) (http.Handler, error) {
+	skills, err := findSkills(fsys)
+	if err != nil {
+		return nil, err
+	}
+	meta := map[string]SkillMeta{}
+	for _, m := range ListSkills(fsys) {
+		meta[m.Name] = m
+	}
+	h := &skillsHandler{files: map[string]served{}}
+	idx := CatalogIndex{Skills: []CatalogSkill{}}
+	for _, s := range skills {
+		if _, dup := h.files[s.name+".zip"]; dup {
+			return nil, fmt.Errorf("instill: more than one skill is named %q", s.name)
+		}
+		files := s.skillFiles()
+		if s.signature != nil {
+			files[SignatureFile] = s.signature
+		}
+		archive, err := zipSkill(s.name, files)
+		if err != nil {
+			return nil, err
+		}
+		h.add(s.name+".zip", archive)
+		for rel, data := range files {
+			h.add(s.name+"/"+rel, data)
+		}
+		idx.Skills = append(idx.Skills, CatalogSkill{
+			Name:        s.name,
+			Description: meta[s.name].Description,
+			Versions: []CatalogRelease{{
+				Version: meta[s.name].Version,
+				URL:     s.name + ".zip",
+				Digest:  hashBytes(archive),
+			}},
+		})
+	}
+	data, err := json.MarshalIndent(idx, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("instill: encoding skills index: %w", err)
+	}
+	h.add("index.json", append(data, '\n'))
+	return h, nil
+}
+
+func (h *skillsHandler) add(name string, data []byte) {
+	h.files[name] = served{data: data, etag: `"` + strings.TrimPrefix(hashBytes(data), "sha256:") + `"`}
+}
+
+func (h *skillsHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+	name, ok := strings.CutPrefix(r.URL.Path, WellKnownPath)
+	f, found := h.files[name]
+	if !ok || !found {
+		http.NotFound(w, r)
+		return
+	}
+	w.Header().Set("ETag", f.etag)
+	w.Header().Set("Cache-Control", "no-cache")
+	if strings.HasSuffix(name, ".md") {
+		w.Header().Set("Content-Type", "text/markdown; charset=utf-8")
+	}
+	http.ServeContent(w, r, name, time.Time{}, bytes.NewReader(f.data))
+}
+
+// zipSkill archives the files of a skill under a directory named after it.
+// The archive is deterministic, so its digest changes only with its content.
+func zipSkill(name string, files map[string][]byte) ([]byte, error) {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, rel := range sortedKeys(files) {
+		w, err := zw.CreateHeader(&zip.FileHeader{Name: name + "/" + rel, Method: zip.Deflate})
+		if err != nil {
+			return nil, fmt.Errorf("instill: archiving %s: %w", name, err)
+		}
+		if _, err := w.Write(files[rel]); err != nil {
+			return nil, fmt.Errorf("instill: archiving %s: %w", name, err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return nil, fmt.Errorf("instill: archiving %s: %w", name, err)
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,146 @@
+package instill
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+)
+
+var servedSkills = fstest.MapFS{
+	"skills/alpha/SKILL.md":           {Data: []byte("---\nname: alpha\ndescription: Alpha helper\nversion: 1.2.0\n---\n# Alpha\n")},
+	"skills/alpha/references/docs.md": {Data: []byte("# Docs\n")},
+	"skills/alpha/_commands/go.md":    {Data: []byte("Go!\n")},
+	"skills/alpha/README.md":          {Data: []byte("not installed\n")},
+	"skills/beta/SKILL.md":            {Data: []byte("---\nname: beta\n---\n")},
+}
+
+func serveSkills(t *testing.T) *httptest.Server {
+	t.Helper()
+	h, err := SkillsHandler(servedSkills)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mux := http.NewServeMux()
+	mux.Handle(WellKnownPath, h)
+	ts := httptest.NewServer(mux)
+	t.Cleanup(ts.Close)
+	return ts
+}
+
+func TestSkillsHandlerIndex(t *testing.T) {
+	ts := serveSkills(t)
+	resp, err := http.Get(ts.URL + WellKnownPath + "index.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	var idx CatalogIndex
+	if err := json.NewDecoder(resp.Body).Decode(&idx); err != nil {
+		t.Fatal(err)
+	}
+	if len(idx.Skills) != 2 {
+		t.Fatalf("index lists %d skills, want 2", len(idx.Skills))
+	}
+	alpha := idx.Skills[0]
+	if alpha.Name != "alpha" || alpha.Description != "Alpha helper" || len(alpha.Versions) != 1 ||
+		alpha.Versions[0].Version != "1.2.0" || alpha.Versions[0].URL != "alpha.zip" {
+		t.Errorf("alpha = %+v", alpha)
+	}
+
+	// Conditional requests are answered from the ETag.
+	req, _ := http.NewRequest(http.MethodGet, ts.URL+WellKnownPath+"index.json", nil)
+	req.Header.Set("If-None-Match", resp.Header.Get("ETag"))
+	resp2, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp2.Body.Close()
+	if resp2.StatusCode != http.StatusNotModified {
+		t.Errorf("conditional request: status %d, want 304", resp2.StatusCode)
+	}
+}
+
+func TestSkillsHandlerFiles(t *testing.T) {
+	ts := serveSkills(t)
+	tests := []struct {
+		path   string
+		status int
+		body   string
+	}{
+		{"alpha/SKILL.md", http.StatusOK, "---\nname: alpha\ndescription: Alpha helper\nversion: 1.2.0\n---\n# Alpha\n"},
+		{"alpha/references/docs.md", http.StatusOK, "# Docs\n"},
+		{"alpha/_commands/go.md", http.StatusOK, "Go!\n"},
+		{"alpha/README.md", http.StatusNotFound, ""},
+		{"gamma.zip", http.StatusNotFound, ""},
+		{"../go.mod", http.StatusNotFound, ""},
+	}
+	for _, tt := range tests {
+		resp, err := http.Get(ts.URL + WellKnownPath + tt.path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if resp.StatusCode != tt.status {
+			t.Errorf("%s: status %d, want %d", tt.path, resp.StatusCode, tt.status)
+		}
+		if tt.status == http.StatusOK {
+			if string(body) != tt.body {
+				t.Errorf("%s: body %q, want %q", tt.path, body, tt.body)
+			}
+			if want := `"` + hashBytes(body)[7:] + `"`; resp.Header.Get("ETag") != want {
+				t.Errorf("%s: ETag %q, want %q", tt.path, resp.Header.Get("ETag"), want)
+			}
+		}
+	}
+
+	resp, err := http.Post(ts.URL+WellKnownPath+"index.json", "text/plain", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusMethodNotAllowed {
+		t.Errorf("POST: status %d, want 405", resp.StatusCode)
+	}
+}
+
+func TestSkillsHandlerCatalog(t *testing.T) {
+	ts := serveSkills(t)
+	c := &Catalog{URL: ts.URL + WellKnownPath + "index.json", Client: ts.Client(), CacheDir: t.TempDir()}
+	project := t.TempDir()
+	results, err := c.Install(context.Background(), "alpha", "", Options{Agents: []string{"claude-code"}, ProjectDir: project})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 1 || len(results[0].Commands) != 1 {
+		t.Fatalf("results = %+v, want alpha with one command", results)
+	}
+	for _, p := range []string{".claude/skills/alpha/references/docs.md", ".claude/commands/go.md"} {
+		if _, err := os.Stat(filepath.Join(project, p)); err != nil {
+			t.Error(err)
+		}
+	}
+
+	// The same content always produces the same archive.
+	h1, _ := SkillsHandler(servedSkills)
+	h2, _ := SkillsHandler(servedSkills)
+	if a, b := h1.(*skillsHandler).files["alpha.zip"].etag, h2.(*skillsHandler).files["alpha.zip"].etag; a != b {
+		t.Errorf("archive ETags differ between handlers: %s, %s", a, b)
+	}
+}
+
+func TestSkillsHandlerDuplicate(t *testing.T) {
+	fsys := fstest.MapFS{
+		"a/x/SKILL.md": {Data: []byte("---\nname: x\n---\n")},
+		"b/x/SKILL.md": {Data: []byte("---\nname: x\n---\n")},
+	}
+	if _, err := SkillsHandler(fsys); err == nil {
+		t.Error("SkillsHandler accepted two skills with the same name")
+	}
+}
@@ -0,0 +1,111 @@
+package instill
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// SignatureFile is the detached signature SignSkills writes next to a
+// skill's SKILL.md. It is never installed itself; the verified signer is
+// recorded in .instill.json instead.
+const SignatureFile = "SKILL.sig"
+
+// signatureContext separates skill signatures from other uses of a key.
+const signatureContext = "instill skill signature v1\x00"
+
+// signature is the content of a SignatureFile.
+type signature struct {
+	Skill     string `json:"skill"`
+	Digest    string `json:"digest"`    // see SkillDigests
+	Key       string `json:"key"`       // see EncodePublicKey
+	Signature string `json:"signature"` // base64 ed25519 signature of signedMessage
+}
+
+// signedMessage is what a skill signature covers: the skill's name and
+// content digest, so that neither can be swapped.
+func signedMessage(skill, digest string) []byte {
+	return []byte(signatureContext + skill + "\x00" + digest)
+}
+
+// EncodePublicKey formats an ed25519 public key as "ed25519:" followed by
+// its standard base64 encoding, the form recorded as a skill's signer.
+func EncodePublicKey(key ed25519.PublicKey) string {
+	return "ed25519:" + base64.StdEncoding.EncodeToString(key)
+}
+
+// ParsePublicKey parses a public key formatted by EncodePublicKey.
+func ParsePublicKey(s string) (ed25519.PublicKey, error) {
+	b64, ok := strings.CutPrefix(strings.TrimSpace(s), "ed25519:")
+	key, err := base64.StdEncoding.DecodeString(b64)
+	if !ok || err != nil || len(key) != ed25519.PublicKeySize {
+		return nil, fmt.Errorf("instill: invalid public key %q (want ed25519:<base64>)", s)
+	}
+	return ed25519.PublicKey(key), nil
+}
+
+// SignSkills signs every skill found in dir with key, writing a
+// SignatureFile next to each SKILL.md. The signature covers the skill's name
+// and its content digest (see SkillDigests), so any change to an installed
+// file invalidates it. It returns the names of the signed skills.
+func SignSkills(dir string, key ed25519.PrivateKey) ([]string, error) {
+	skills, err := findSkills(os.DirFS(dir))
+	if err != nil {
+		return nil, err
+	}
+	if len(skills) == 0 {
+		return nil, fmt.Errorf("instill: no SKILL.md found in %s", dir)
+	}
+	var names []string
+	for _, s := range skills {
+		d := digest(hashFiles(s.skillFiles()))
+		sig := signature{
+			Skill:     s.name,
+			Digest:    d,
+			Key:       EncodePublicKey(key.Public().(ed25519.PublicKey)),
+			Signature: base64.StdEncoding.EncodeToString(ed25519.Sign(key, signedMessage(s.name, d))),
+		}
+		data, _ := json.MarshalIndent(sig, "", "  ")
+		p := filepath.Join(dir, filepath.FromSlash(s.dir), SignatureFile)
+		if err := os.WriteFile(p, append(data, '\n'), 0o644); err != nil {
+			return nil, fmt.Errorf("instill: writing signature: %w", err)
+		}
+		names = append(names, s.name)
+	}
+	return names, nil
+}
+
+// ErrUntrusted is returned, wrapped, when Options.TrustedKeys is set and a
+// skill is unsigned, its signature is invalid, or its signer is not trusted.
+var ErrUntrusted = errors.New("skill signature not trusted")
+
+// checkSignature verifies the signature of s against trusted keys and
+// returns the signer.
+func checkSignature(s skillEntry, trusted []ed25519.PublicKey) (string, error) {
+	if s.signature == nil {
+		return "", fmt.Errorf("instill: skill %q: %w: no %s", s.name, ErrUntrusted, SignatureFile)
+	}
+	var sig signature
+	if err := json.Unmarshal(s.signature, &sig); err != nil {
+		return "", fmt.Errorf("instill: skill %q: %w: parsing %s: %v", s.name, ErrUntrusted, SignatureFile, err)
+	}
+	key, err := ParsePublicKey(sig.Key)
+	if err != nil {
+		return "", fmt.Errorf("instill: skill %q: %w: invalid key %q", s.name, ErrUntrusted, sig.Key)
+	}
+	if !slices.ContainsFunc(trusted, func(k ed25519.PublicKey) bool { return k.Equal(key) }) {
+		return "", fmt.Errorf("instill: skill %q: %w: signed by unknown key %s", s.name, ErrUntrusted, sig.Key)
+	}
+	raw, err := base64.StdEncoding.DecodeString(sig.Signature)
+	d := digest(hashFiles(s.skillFiles()))
+	if err != nil || sig.Skill != s.name || sig.Digest != d || !ed25519.Verify(key, signedMessage(s.name, d), raw) {
+		return "", fmt.Errorf("instill: skill %q: %w: signature does not match its content", s.name, ErrUntrusted)
+	}
+	return sig.Key, nil
+}
@@ -0,0 +1,139 @@
+package instill
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"errors"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func testKey(seed byte) ed25519.PrivateKey {
+	return ed25519.NewKeyFromSeed(bytes.Repeat([]byte{seed}, ed25519.SeedSize))
+}
+
+func signedSkills(t *testing.T, key ed25519.PrivateKey) string {
+	t.Helper()
+	dir := t.TempDir()
+	writeTestSkill(t, dir, "alpha", "---\nname: alpha\nversion: 1.0.0\n---\n# Alpha\n")
+	if err := os.MkdirAll(filepath.Join(dir, "alpha/_commands"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "alpha/_commands/go.md"), []byte("Go!\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	names, err := SignSkills(dir, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(names) != 1 || names[0] != "alpha" {
+		t.Fatalf("SignSkills = %v, want [alpha]", names)
+	}
+	return dir
+}
+
+func writeTestSkill(t *testing.T, dir, name, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Join(dir, name), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, name, "SKILL.md"), []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSignedInstall(t *testing.T) {
+	key := testKey(1)
+	src := signedSkills(t, key)
+	project := t.TempDir()
+	opts := Options{Agents: []string{"claude-code"}, ProjectDir: project, TrustedKeys: []ed25519.PublicKey{testKey(2).Public().(ed25519.PublicKey), key.Public().(ed25519.PublicKey)}}
+	if _, err := Install(os.DirFS(src), opts); err != nil {
+		t.Fatal(err)
+	}
+	skillDir := filepath.Join(project, ".claude/skills/alpha")
+	signer := EncodePublicKey(key.Public().(ed25519.PublicKey))
+	if got := readManifest(OSFS{}, skillDir, discard).Signer; got != signer {
+		t.Errorf("manifest signer = %q, want %q", got, signer)
+	}
+	if _, err := os.Stat(filepath.Join(skillDir, SignatureFile)); !os.IsNotExist(err) {
+		t.Errorf("%s was installed: %v", SignatureFile, err)
+	}
+	installed, err := ListInstalled(Options{Agents: []string{"claude-code"}, ProjectDir: project})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(installed) != 1 || installed[0].Signer != signer {
+		t.Errorf("ListInstalled = %+v, want alpha signed by %s", installed, signer)
+	}
+}
+
+func TestSignedInstallRejected(t *testing.T) {
+	key := testKey(1)
+	trusted := []ed25519.PublicKey{key.Public().(ed25519.PublicKey)}
+	tests := []struct {
+		name   string
+		mutate func(t *testing.T, dir string)
+		keys   []ed25519.PublicKey
+		want   string
+	}{
+		{"modified file", func(t *testing.T, dir string) {
+			os.WriteFile(filepath.Join(dir, "alpha/SKILL.md"), []byte("---\nname: alpha\n---\nrm -rf /\n"), 0o644)
+		}, trusted, "does not match"},
+		{"modified command", func(t *testing.T, dir string) {
+			os.WriteFile(filepath.Join(dir, "alpha/_commands/go.md"), []byte("Stop!\n"), 0o644)
+		}, trusted, "does not match"},
+		{"added file", func(t *testing.T, dir string) {
+			os.WriteFile(filepath.Join(dir, "alpha/extra.md"), []byte("extra\n"), 0o644)
+		}, trusted, "does not match"},
+		{"unsigned", func(t *testing.T, dir string) {
+			os.Remove(filepath.Join(dir, "alpha", SignatureFile))
+		}, trusted, "no " + SignatureFile},
+		{"untrusted key", func(t *testing.T, dir string) {}, []ed25519.PublicKey{testKey(2).Public().(ed25519.PublicKey)}, "unknown key"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			src := signedSkills(t, key)
+			tt.mutate(t, src)
+			project := t.TempDir()
+			_, err := Install(os.DirFS(src), Options{Agents: []string{"claude-code"}, ProjectDir: project, TrustedKeys: tt.keys})
+			if !errors.Is(err, ErrUntrusted) || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("err = %v, want ErrUntrusted containing %q", err, tt.want)
+			}
+			if _, err := os.Stat(filepath.Join(project, ".claude")); !os.IsNotExist(err) {
+				t.Errorf("rejected skill was installed: %v", err)
+			}
+		})
+	}
+}
+
+func TestSignedCatalogInstall(t *testing.T) {
+	key := testKey(1)
+	h, err := SkillsHandler(os.DirFS(signedSkills(t, key)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ts := httptest.NewServer(h)
+	defer ts.Close()
+	c := &Catalog{URL: ts.URL + WellKnownPath + "index.json", Client: ts.Client(), CacheDir: t.TempDir()}
+	opts := Options{Agents: []string{"claude-code"}, ProjectDir: t.TempDir(), TrustedKeys: []ed25519.PublicKey{key.Public().(ed25519.PublicKey)}}
+	if _, err := c.Install(context.Background(), "alpha", "", opts); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestParsePublicKey(t *testing.T) {
+	pub := testKey(3).Public().(ed25519.PublicKey)
+	got, err := ParsePublicKey(EncodePublicKey(pub) + "\n")
+	if err != nil || !got.Equal(pub) {
+		t.Errorf("ParsePublicKey(EncodePublicKey(k)) = %v, %v", got, err)
+	}
+	for _, s := range []string{"", "ed25519:", "rsa:AAAA", "ed25519:AAAA"} {
+		if _, err := ParsePublicKey(s); err == nil {
+			t.Errorf("ParsePublicKey(%q) succeeded", s)
+		}
+	}
+}
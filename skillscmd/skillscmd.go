@@ -0,0 +1,448 @@
+// Package skillscmd provides a ready-made "skills" command tree for CLI tools
+// that bundle their own Agent Skills.
+//
+// The tree (install, update, remove, status, doctor) parses its own flags, so
+// it plugs into any CLI framework as a pass-through subcommand:
+//
+//	//go:embed skills
+//	var skills embed.FS
+//
+//	cmd := skillscmd.New(skills, skillscmd.Config{Name: "mytool"})
+//	err := cmd.Run(ctx, os.Args[2:]) // everything after "mytool skills"
+package skillscmd
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/tiulpin/instill"
+)
+
+// Config customizes the command tree.
+type Config struct {
+	Name   string    // host tool name shown in help, e.g. "mytool"
+	Stdin  io.Reader // source of confirmation answers; defaults to os.Stdin
+	Stdout io.Writer // defaults to os.Stdout
+	Stderr io.Writer // defaults to os.Stderr
+}
+
+// Command is the "skills" command tree for one set of bundled skills.
+type Command struct {
+	fsys fs.FS
+	cfg  Config
+}
+
+// New returns the command tree for the skills in fsys.
+func New(fsys fs.FS, cfg Config) *Command {
+	if cfg.Name == "" {
+		cfg.Name = "tool"
+	}
+	if cfg.Stdout == nil {
+		cfg.Stdout = os.Stdout
+	}
+	if cfg.Stderr == nil {
+		cfg.Stderr = os.Stderr
+	}
+	return &Command{fsys: fsys, cfg: cfg}
+}
+
+// ErrUsage is returned, wrapped, for invalid command lines.
+var ErrUsage = errors.New("usage error")
+
+type subcommand struct {
+	name, summary string
+	run           func(*Command, context.Context, []string) error
+}
+
+var subcommands = []subcommand{
+	{"install", "install bundled skills into agent skill directories", (*Command).install},
+	{"update", "update installed skills that are out of date", (*Command).update},
+	{"remove", "remove bundled skills from agent skill directories", (*Command).remove},
+	{"status", "show which skills are installed for which agents", (*Command).status},
+	{"doctor", "diagnose why skills are not picked up by an agent", (*Command).doctor},
+}
+
+// Short is a one-line description suitable for the host CLI's command list.
+const Short = "Manage agent skills bundled with this tool"
+
+// Usage returns the help text for the command tree.
+func (c *Command) Usage() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "usage: %s skills <command> [flags]\n\nCommands:\n", c.cfg.Name)
+	for _, s := range subcommands {
+		fmt.Fprintf(&b, "  %-8s %s\n", s.name, s.summary)
+	}
+	fmt.Fprintf(&b, "\nRun '%s skills <command> -h' for command flags.\n", c.cfg.Name)
+	return b.String()
+}
+
+// Run executes the subcommand named by args[0]. Help requests return nil.
+func (c *Command) Run(ctx context.Context, args []string) error {
+	if len(args) == 0 || args[0] == "help" || args[0] == "-h" || args[0] == "--help" {
+		fmt.Fprint(c.cfg.Stdout, c.Usage())
+		return nil
+	}
+	for _, s := range subcommands {
+		if s.name == args[0] {
+			err := s.run(c, ctx, args[1:])
+			if errors.Is(err, flag.ErrHelp) {
+				return nil
+			}
+			return err
+		}
+	}
+	fmt.Fprint(c.cfg.Stderr, c.Usage())
+	return fmt.Errorf("%w: unknown command %q", ErrUsage, args[0])
+}
+
+// targetFlags mirror instill.Options.
+type targetFlags struct {
+	agents  string
+	project string
+	global  bool
+	yes     bool
+	dryRun  bool
+}
+
+func (c *Command) flags(name string, t *targetFlags, confirm bool) *flag.FlagSet {
+	fs := flag.NewFlagSet(c.cfg.Name+" skills "+name, flag.ContinueOnError)
+	fs.SetOutput(c.cfg.Stderr)
+	fs.StringVar(&t.agents, "agent", "", "comma-separated agents to target (default: detected agents)")
+	fs.StringVar(&t.project, "project", ".", "project root for project-level skills")
+	fs.BoolVar(&t.global, "global", false, "use global skill directories instead of project-level ones")
+	if confirm {
+		fs.BoolVar(&t.yes, "yes", false, "do not ask for confirmation")
+		fs.BoolVar(&t.dryRun, "dry-run", false, "show what would change without changing anything")
+	}
+	return fs
+}
+
+func (c *Command) parse(fs *flag.FlagSet, args []string) error {
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return err
+		}
+		return fmt.Errorf("%w: %v", ErrUsage, err)
+	}
+	return nil
+}
+
+// options resolves target flags, defaulting to the detected agents.
+func (c *Command) options(t *targetFlags) (instill.Options, error) {
+	opts := instill.Options{ProjectDir: t.project, Global: t.global}
+	for a := range strings.SplitSeq(t.agents, ",") {
+		if a = strings.TrimSpace(a); a != "" {
+			opts.Agents = append(opts.Agents, a)
+		}
+	}
+	if len(opts.Agents) > 0 {
+		return opts, nil
+	}
+	detected, err := instill.Detect(opts.ProjectDir, opts.Global)
+	if err != nil {
+		return opts, err
+	}
+	for _, a := range detected {
+		opts.Agents = append(opts.Agents, a.Name)
+	}
+	if len(opts.Agents) == 0 {
+		return opts, fmt.Errorf("%w: no agents detected; pass --agent (see '%s skills doctor')", ErrUsage, c.cfg.Name)
+	}
+	return opts, nil
+}
+
+func (c *Command) install(ctx context.Context, args []string) error {
+	var t targetFlags
+	fs := c.flags("install", &t, true)
+	if err := c.parse(fs, args); err != nil {
+		return err
+	}
+	opts, err := c.options(&t)
+	if err != nil {
+		return err
+	}
+	opts.Skills = fs.Args()
+	plan, err := instill.PlanInstall(c.fsys, opts)
+	if err != nil {
+		return err
+	}
+	return c.apply(ctx, plan, t, "installed")
+}
+
+func (c *Command) update(ctx context.Context, args []string) error {
+	var t targetFlags
+	fs := c.flags("update", &t, true)
+	if err := c.parse(fs, args); err != nil {
+		return err
+	}
+	opts, err := c.options(&t)
+	if err != nil {
+		return err
+	}
+	// Only update copies that are installed and outdated, one plan per skill
+	// so that agents sharing a directory are planned together.
+	updates, err := instill.CheckUpdates(c.fsys, opts)
+	if err != nil {
+		return err
+	}
+	stale := map[string][]string{}
+	var skills []string
+	for _, u := range updates {
+		if !u.Outdated() {
+			continue
+		}
+		if _, ok := stale[u.Skill]; !ok {
+			skills = append(skills, u.Skill)
+		}
+		stale[u.Skill] = append(stale[u.Skill], u.Agent)
+	}
+	combined := &instill.Plan{}
+	for _, name := range skills {
+		one := opts
+		one.Agents, one.Skills = stale[name], []string{name}
+		plan, err := instill.PlanInstall(c.fsys, one)
+		if err != nil {
+			return err
+		}
+		if err := combined.Append(plan); err != nil {
+			return err
+		}
+	}
+	if len(combined.Ops) == 0 {
+		fmt.Fprintln(c.cfg.Stdout, "all installed skills are up to date")
+		return nil
+	}
+	return c.apply(ctx, combined, t, "updated")
+}
+
+func (c *Command) remove(ctx context.Context, args []string) error {
+	var t targetFlags
+	fs := c.flags("remove", &t, true)
+	if err := c.parse(fs, args); err != nil {
+		return err
+	}
+	opts, err := c.options(&t)
+	if err != nil {
+		return err
+	}
+	names := fs.Args()
+	if len(names) == 0 {
+		for _, s := range instill.ListSkills(c.fsys) {
+			names = append(names, s.Name)
+		}
+	}
+	combined := &instill.Plan{}
+	for _, name := range names {
+		plan, err := instill.PlanRemove(name, opts)
+		if err != nil {
+			return err
+		}
+		if err := combined.Append(plan); err != nil {
+			return err
+		}
+	}
+	return c.apply(ctx, combined, t, "removed")
+}
+
+// apply shows the plan, asks for confirmation and applies it.
+func (c *Command) apply(ctx context.Context, plan *instill.Plan, t targetFlags, verb string) error {
+	if len(plan.Ops) == 0 {
+		fmt.Fprintln(c.cfg.Stdout, "nothing to do")
+		return nil
+	}
+	if t.dryRun || !t.yes {
+		for _, op := range plan.Ops {
+			fmt.Fprintf(c.cfg.Stdout, "  %-9s %s\n", op.Kind, op.Path)
+		}
+	}
+	if t.dryRun {
+		return nil
+	}
+	if !t.yes {
+		ok, err := c.confirm("Proceed?")
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return errors.New("aborted")
+		}
+	}
+	results, err := plan.ApplyContext(ctx)
+	if err != nil {
+		return err
+	}
+	for _, r := range results {
+		if verb == "removed" && !r.Existed {
+			continue
+		}
+		fmt.Fprintf(c.cfg.Stdout, "%s %s for %s (%s)\n", verb, r.Skill, r.Agent, r.Path)
+	}
+	return nil
+}
+
+// confirm asks a yes/no question. Without an interactive stdin nobody can
+// answer, so it refuses rather than changing anything unasked.
+func (c *Command) confirm(question string) (bool, error) {
+	in := c.cfg.Stdin
+	if in == nil {
+		if fi, err := os.Stdin.Stat(); err != nil || fi.Mode()&os.ModeCharDevice == 0 {
+			return false, fmt.Errorf("%w: stdin is not a terminal; pass --yes to proceed without confirmation", ErrUsage)
+		}
+		in = os.Stdin
+	}
+	fmt.Fprintf(c.cfg.Stdout, "%s [y/N] ", question)
+	answer, _ := bufio.NewReader(in).ReadString('\n')
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes", nil
+}
+
+func (c *Command) status(_ context.Context, args []string) error {
+	var t targetFlags
+	fs := c.flags("status", &t, false)
+	if err := c.parse(fs, args); err != nil {
+		return err
+	}
+	opts, err := c.options(&t)
+	if err != nil {
+		return err
+	}
+	tw := tabwriter.NewWriter(c.cfg.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "SKILL\tAGENT\tBUNDLED\tINSTALLED\tSTATUS")
+	updates, err := instill.CheckUpdates(c.fsys, opts)
+	if err != nil {
+		return err
+	}
+	for _, u := range updates {
+		installed := u.Installed
+		if u.Status == instill.UpdateMissing {
+			installed = "-"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", u.Skill, u.Agent, dash(u.Bundled), dash(installed), statusText[u.Status])
+	}
+	return tw.Flush()
+}
+
+func (c *Command) doctor(_ context.Context, args []string) error {
+	var t targetFlags
+	fs := c.flags("doctor", &t, false)
+	if err := c.parse(fs, args); err != nil {
+		return err
+	}
+	out := c.cfg.Stdout
+	problems := 0
+
+	if rt := instill.DetectRuntime(); rt != nil {
+		fmt.Fprintf(out, "running inside %s (detected via %s)\n", rt.DisplayName, rt.EnvVar)
+	} else {
+		fmt.Fprintln(out, "not running inside an agent")
+	}
+
+	diags, err := instill.Validate(c.fsys)
+	if err != nil {
+		return err
+	}
+	for _, d := range diags {
+		fmt.Fprintf(out, "bundled skill: %s\n", d)
+		if d.Severity == instill.SeverityError {
+			problems++
+		}
+	}
+
+	bundled := instill.ListSkills(c.fsys)
+	for _, global := range []bool{false, true} {
+		scope := "project"
+		if global {
+			scope = "global"
+		}
+		detected, err := instill.Detect(t.project, global)
+		if err != nil {
+			return err
+		}
+		if len(detected) == 0 {
+			fmt.Fprintf(out, "%s: no agents detected\n", scope)
+			continue
+		}
+		names := make([]string, len(detected))
+		for i, a := range detected {
+			names[i] = a.Name
+		}
+		installed, err := installedSkills(instill.Options{Agents: names, ProjectDir: t.project, Global: global})
+		if err != nil {
+			return err
+		}
+		for _, a := range detected {
+			var missing []string
+			for _, s := range bundled {
+				if !installed[a.Name][s.Name] {
+					missing = append(missing, s.Name)
+				}
+			}
+			dir := a.ProjectDir
+			if global {
+				dir = a.GlobalDir
+			}
+			if len(missing) == 0 {
+				fmt.Fprintf(out, "%s: %s: all skills installed in %s\n", scope, a.DisplayName, dir)
+				continue
+			}
+			fmt.Fprintf(out, "%s: %s: missing %s in %s (run '%s skills install --agent %s", scope, a.DisplayName, strings.Join(missing, ", "), dir, c.cfg.Name, a.Name)
+			if global {
+				fmt.Fprint(out, " --global")
+			}
+			fmt.Fprintln(out, "')")
+		}
+	}
+	if problems > 0 {
+		return fmt.Errorf("%d problem(s) found", problems)
+	}
+	return nil
+}
+
+// installedSkills returns the names of the skills installed for each of the
+// targets of opts, in the scope of opts.
+func installedSkills(opts instill.Options) (map[string]map[string]bool, error) {
+	skills, err := instill.ListInstalled(opts)
+	if err != nil {
+		return nil, err
+	}
+	scope := instill.ScopeProject
+	if opts.Global {
+		scope = instill.ScopeGlobal
+	}
+	out := map[string]map[string]bool{}
+	for _, s := range skills {
+		if s.Scope != scope {
+			continue
+		}
+		for _, a := range s.Agents {
+			if out[a] == nil {
+				out[a] = map[string]bool{}
+			}
+			out[a][s.Name] = true
+		}
+	}
+	return out, nil
+}
+
+var statusText = map[instill.UpdateStatus]string{
+	instill.UpdateMissing: "not installed",
+	instill.UpdateOlder:   "update available",
+	instill.UpdateEqual:   "up to date",
+	instill.UpdateNewer:   "newer than bundled",
+	instill.UpdateDiffers: "update available",
+}
+
+func dash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
@@ -0,0 +1,186 @@
+package skillscmd
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func bundled(version string) fstest.MapFS {
+	return fstest.MapFS{
+		"skills/x/SKILL.md": &fstest.MapFile{Data: []byte("---\nname: x\ndescription: d\nversion: \"" + version + "\"\n---\n")},
+	}
+}
+
+func newTest(fsys fstest.MapFS, stdin string) (*Command, *bytes.Buffer) {
+	var out bytes.Buffer
+	return New(fsys, Config{Name: "mytool", Stdin: strings.NewReader(stdin), Stdout: &out, Stderr: &out}), &out
+}
+
+func TestInstallConfirm(t *testing.T) {
+	project := t.TempDir()
+	cmd, out := newTest(bundled("1.0"), "n\n")
+	err := cmd.Run(context.Background(), []string{"install", "--agent", "claude-code", "--project", project})
+	if err == nil || !strings.Contains(out.String(), "Proceed?") {
+		t.Fatalf("expected abort after prompt, got %v:\n%s", err, out)
+	}
+	if _, err := os.Stat(filepath.Join(project, ".claude")); !os.IsNotExist(err) {
+		t.Fatal("declined install must not write")
+	}
+
+	cmd, out = newTest(bundled("1.0"), "y\n")
+	if err := cmd.Run(context.Background(), []string{"install", "--agent", "claude-code", "--project", project}); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), "installed x for claude-code") {
+		t.Errorf("unexpected output:\n%s", out)
+	}
+}
+
+func TestConfirmNonInteractive(t *testing.T) {
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	w.WriteString("y\n")
+	w.Close()
+	stdin := os.Stdin
+	os.Stdin = r
+	defer func() { os.Stdin = stdin }()
+
+	project := t.TempDir()
+	var out bytes.Buffer
+	cmd := New(bundled("1.0"), Config{Name: "mytool", Stdout: &out, Stderr: &out})
+	err = cmd.Run(context.Background(), []string{"install", "--agent", "claude-code", "--project", project})
+	if !errors.Is(err, ErrUsage) || !strings.Contains(err.Error(), "--yes") {
+		t.Fatalf("piped stdin: err = %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(project, ".claude")); !os.IsNotExist(err) {
+		t.Fatal("unconfirmed install must not write")
+	}
+	if err := cmd.Run(context.Background(), []string{"install", "--yes", "--agent", "claude-code", "--project", project}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestUpdateAndStatus(t *testing.T) {
+	project := t.TempDir()
+	ctx := context.Background()
+	args := []string{"--agent", "claude-code,cursor", "--project", project}
+
+	cmd, _ := newTest(bundled("1.0"), "")
+	if err := cmd.Run(ctx, []string{"install", "--yes", "--agent", "claude-code", "--project", project}); err != nil {
+		t.Fatal(err)
+	}
+
+	cmd, out := newTest(bundled("2.0"), "")
+	if err := cmd.Run(ctx, append([]string{"status"}, args...)); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"claude-code  2.0      1.0        update available", "cursor       2.0      -          not installed"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("status missing %q:\n%s", want, out)
+		}
+	}
+
+	cmd, out = newTest(bundled("2.0"), "")
+	if err := cmd.Run(ctx, append([]string{"update", "--yes"}, args...)); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), "updated x for claude-code") || strings.Contains(out.String(), "cursor") {
+		t.Errorf("update should only touch installed copies:\n%s", out)
+	}
+
+	cmd, out = newTest(bundled("2.0"), "")
+	if err := cmd.Run(ctx, append([]string{"update", "--yes"}, args...)); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), "up to date") {
+		t.Errorf("expected up to date:\n%s", out)
+	}
+}
+
+func TestRemove(t *testing.T) {
+	project := t.TempDir()
+	ctx := context.Background()
+	cmd, out := newTest(bundled("1.0"), "")
+	if err := cmd.Run(ctx, []string{"install", "--yes", "--agent", "cursor", "--project", project}); err != nil {
+		t.Fatal(err)
+	}
+	if err := cmd.Run(ctx, []string{"remove", "--yes", "--agent", "cursor,claude-code", "--project", project}); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), "removed x for cursor") || strings.Contains(out.String(), "for claude-code") {
+		t.Errorf("unexpected output:\n%s", out)
+	}
+	if _, err := os.Stat(filepath.Join(project, ".agents/skills/x")); !os.IsNotExist(err) {
+		t.Error("skill should be removed")
+	}
+}
+
+func TestDryRun(t *testing.T) {
+	project := t.TempDir()
+	cmd, out := newTest(bundled("1.0"), "")
+	if err := cmd.Run(context.Background(), []string{"install", "--dry-run", "--agent", "cursor", "--project", project}); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), "write") {
+		t.Errorf("expected planned writes:\n%s", out)
+	}
+	if _, err := os.Stat(filepath.Join(project, ".agents")); !os.IsNotExist(err) {
+		t.Fatal("dry run must not write")
+	}
+}
+
+func TestDoctor(t *testing.T) {
+	project := t.TempDir()
+	t.Setenv("HOME", project)
+	t.Setenv("USERPROFILE", project)
+	t.Setenv("CLAUDE_CONFIG_DIR", filepath.Join(project, ".claude"))
+	if err := os.MkdirAll(filepath.Join(project, ".claude"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	cmd, out := newTest(bundled("1.0"), "")
+	if err := cmd.Run(context.Background(), []string{"doctor", "--project", project}); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), "Claude Code: missing x") {
+		t.Errorf("doctor should report the missing skill:\n%s", out)
+	}
+
+	cmd, out = newTest(bundled("1.0"), "")
+	if err := cmd.Run(context.Background(), []string{"install", "--agent", "claude-code", "--project", project, "--yes"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := cmd.Run(context.Background(), []string{"doctor", "--project", project}); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), "project: Claude Code: all skills installed") {
+		t.Errorf("doctor should find the installed skill:\n%s", out)
+	}
+
+	bad := fstest.MapFS{"skills/x/SKILL.md": &fstest.MapFile{Data: []byte("---\nname: Y\n---\n")}}
+	cmd, _ = newTest(bad, "")
+	if err := cmd.Run(context.Background(), []string{"doctor", "--project", project}); err == nil {
+		t.Error("doctor should fail on invalid bundled skills")
+	}
+}
+
+func TestUsage(t *testing.T) {
+	cmd, out := newTest(bundled("1.0"), "")
+	if err := cmd.Run(context.Background(), nil); err != nil || !strings.Contains(out.String(), "mytool skills <command>") {
+		t.Errorf("help: %v\n%s", err, out)
+	}
+	if err := cmd.Run(context.Background(), []string{"bogus"}); !errors.Is(err, ErrUsage) {
+		t.Errorf("expected ErrUsage, got %v", err)
+	}
+	if err := cmd.Run(context.Background(), []string{"install", "--bogus"}); !errors.Is(err, ErrUsage) {
+		t.Errorf("expected ErrUsage, got %v", err)
+	}
+}
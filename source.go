@@ -0,0 +1,185 @@
+package instill
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path"
+	"strings"
+	"testing/fstest"
+)
+
+// maxSourceSize limits the total uncompressed size of an extracted source,
+// so that a malicious archive cannot exhaust memory.
+const maxSourceSize = 256 << 20
+
+// FromTarGz reads a gzip-compressed tar archive of skills into memory.
+// Only regular files are extracted; links and special files are skipped.
+// Entries that would escape the archive root are rejected.
+func FromTarGz(r io.Reader) (fs.FS, error) {
+	zr, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, fmt.Errorf("instill: reading tar.gz: %w", err)
+	}
+	defer zr.Close()
+	return fromTar(zr, "")
+}
+
+// fromTar extracts the regular files under prefix in a tar stream.
+func fromTar(r io.Reader, prefix string) (fs.FS, error) {
+	x := newExtractor(prefix)
+	tr := tar.NewReader(r)
+	for {
+		h, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("instill: reading tar: %w", err)
+		}
+		if h.Typeflag != tar.TypeReg {
+			continue
+		}
+		if err := x.add(h.Name, h.Size, tr); err != nil {
+			return nil, err
+		}
+	}
+	return x.fs()
+}
+
+// FromZip reads a zip archive of skills into memory. Claude .skill files are
+// zip archives and are read the same way. Only regular files are extracted,
+// and entries that would escape the archive root are rejected.
+func FromZip(r io.ReaderAt, size int64) (fs.FS, error) {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, fmt.Errorf("instill: reading zip: %w", err)
+	}
+	x := newExtractor("")
+	for _, f := range zr.File {
+		if !f.Mode().IsRegular() {
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return nil, fmt.Errorf("instill: reading zip entry %s: %w", f.Name, err)
+		}
+		err = x.add(f.Name, int64(f.UncompressedSize64), rc)
+		rc.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+	return x.fs()
+}
+
+// OpenArchive reads a .zip, .skill, .tar.gz or .tgz file into memory; see
+// FromZip and FromTarGz. Files with other extensions are recognized by content.
+func OpenArchive(name string) (fs.FS, error) {
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return nil, fmt.Errorf("instill: %w", err)
+	}
+	return fromArchive(name, data)
+}
+
+// fromArchive reads an archive by the extension of name, or by its content
+// when the extension is not recognized.
+func fromArchive(name string, data []byte) (fs.FS, error) {
+	lower := strings.ToLower(name)
+	switch {
+	case strings.HasSuffix(lower, ".zip"), strings.HasSuffix(lower, ".skill"),
+		bytes.HasPrefix(data, []byte("PK\x03\x04")):
+		return FromZip(bytes.NewReader(data), int64(len(data)))
+	case strings.HasSuffix(lower, ".tar.gz"), strings.HasSuffix(lower, ".tgz"),
+		bytes.HasPrefix(data, []byte("\x1f\x8b")):
+		return FromTarGz(bytes.NewReader(data))
+	}
+	return nil, fmt.Errorf("instill: %s: unsupported archive type (want .zip, .skill, .tar.gz or .tgz)", name)
+}
+
+// FromGit reads the skills in subdir of a local git repository at ref (a
+// branch, tag or commit) into memory, without touching the working tree.
+// An empty subdir reads the whole tree. It runs git archive, so git must be
+// installed.
+func FromGit(repo, ref, subdir string) (fs.FS, error) {
+	if ref == "" || strings.HasPrefix(ref, "-") {
+		return nil, fmt.Errorf("instill: invalid git ref %q", ref)
+	}
+	prefix := ""
+	args := []string{"-C", repo, "archive", "--format=tar", ref}
+	if subdir = strings.Trim(path.Clean("/"+strings.ReplaceAll(subdir, `\`, "/")), "/"); subdir != "" {
+		prefix = subdir + "/"
+		args = append(args, "--", subdir)
+	}
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command("git", args...)
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("instill: git archive %s: %s", ref, msg)
+		}
+		return nil, fmt.Errorf("instill: git archive %s: %w", ref, err)
+	}
+	return fromTar(&stdout, prefix)
+}
+
+// extractor collects archive entries, enforcing path safety and size limits.
+type extractor struct {
+	prefix string
+	files  map[string][]byte
+	total  int64
+}
+
+func newExtractor(prefix string) *extractor {
+	return &extractor{prefix: prefix, files: map[string][]byte{}}
+}
+
+func (x *extractor) add(name string, size int64, r io.Reader) error {
+	name = strings.TrimPrefix(name, "./")
+	if strings.Contains(name, `\`) || strings.HasPrefix(name, "/") || !fs.ValidPath(strings.TrimSuffix(name, "/")) {
+		return fmt.Errorf("instill: archive entry %q escapes the archive root", name)
+	}
+	rel, ok := strings.CutPrefix(name, x.prefix)
+	if !ok {
+		return nil
+	}
+	if size < 0 || x.total+size > maxSourceSize {
+		return fmt.Errorf("instill: archive is larger than %d bytes", maxSourceSize)
+	}
+	data, err := io.ReadAll(io.LimitReader(r, size+1))
+	if err != nil {
+		return fmt.Errorf("instill: reading archive entry %s: %w", name, err)
+	}
+	if int64(len(data)) != size {
+		return fmt.Errorf("instill: archive entry %s: size mismatch", name)
+	}
+	x.total += size
+	x.files[rel] = data
+	return nil
+}
+
+func (x *extractor) fs() (fs.FS, error) {
+	for p := range x.files {
+		for d := path.Dir(p); d != "."; d = path.Dir(d) {
+			if _, ok := x.files[d]; ok {
+				return nil, fmt.Errorf("instill: archive has both a file and a directory named %s", d)
+			}
+		}
+	}
+	if len(x.files) == 0 {
+		return nil, errors.New("instill: archive contains no files")
+	}
+	m := make(fstest.MapFS, len(x.files))
+	for p, data := range x.files {
+		m[p] = &fstest.MapFile{Data: data, Mode: 0o644}
+	}
+	return m, nil
+}
@@ -0,0 +1,189 @@
+package instill
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+var packFiles = map[string]string{
+	"pack/alpha/SKILL.md":           "---\nname: alpha\n---\n",
+	"pack/alpha/references/docs.md": "# Docs\n",
+	"pack/beta/SKILL.md":            "---\nname: beta\n---\n",
+}
+
+func tarGz(t *testing.T, files map[string]string, extra ...*tar.Header) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(zw)
+	for _, h := range extra {
+		tw.WriteHeader(h)
+	}
+	for _, name := range sortedKeys(toBytes(files)) {
+		tw.WriteHeader(&tar.Header{Name: name, Mode: 0o644, Size: int64(len(files[name])), Typeflag: tar.TypeReg})
+		tw.Write([]byte(files[name]))
+	}
+	tw.Close()
+	zw.Close()
+	return buf.Bytes()
+}
+
+func zipData(t *testing.T, files map[string]string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, name := range sortedKeys(toBytes(files)) {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write([]byte(files[name]))
+	}
+	zw.Close()
+	return buf.Bytes()
+}
+
+func toBytes(files map[string]string) map[string][]byte {
+	out := map[string][]byte{}
+	for k, v := range files {
+		out[k] = []byte(v)
+	}
+	return out
+}
+
+func checkPack(t *testing.T, fsys fs.FS, err error) {
+	t.Helper()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := fstest.TestFS(fsys, "pack/alpha/SKILL.md", "pack/alpha/references/docs.md", "pack/beta/SKILL.md"); err != nil {
+		t.Fatal(err)
+	}
+	skills := ListSkills(fsys)
+	if len(skills) != 2 || skills[0].Name != "alpha" || skills[1].Name != "beta" {
+		t.Errorf("ListSkills = %+v", skills)
+	}
+}
+
+func TestFromTarGz(t *testing.T) {
+	link := &tar.Header{Name: "pack/evil", Linkname: "/etc/passwd", Typeflag: tar.TypeSymlink}
+	fsys, err := FromTarGz(bytes.NewReader(tarGz(t, packFiles, link)))
+	checkPack(t, fsys, err)
+	if _, err := fs.Stat(fsys, "pack/evil"); err == nil {
+		t.Error("symlinks must not be extracted")
+	}
+
+	project := t.TempDir()
+	if _, err := Install(fsys, Options{Agents: []string{"claude-code"}, ProjectDir: project}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(project, ".claude/skills/alpha/references/docs.md")); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestFromZip(t *testing.T) {
+	data := zipData(t, packFiles)
+	fsys, err := FromZip(bytes.NewReader(data), int64(len(data)))
+	checkPack(t, fsys, err)
+
+	// A .skill file holds one skill directory.
+	dir := t.TempDir()
+	skill := filepath.Join(dir, "alpha.skill")
+	os.WriteFile(skill, zipData(t, map[string]string{"alpha/SKILL.md": "---\nname: alpha\n---\n"}), 0o644)
+	fsys, err = OpenArchive(skill)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := ListSkills(fsys); len(s) != 1 || s[0].Name != "alpha" {
+		t.Errorf("ListSkills = %+v", s)
+	}
+}
+
+func TestOpenArchive(t *testing.T) {
+	dir := t.TempDir()
+	tgz := filepath.Join(dir, "pack.TGZ")
+	os.WriteFile(tgz, tarGz(t, packFiles), 0o644)
+	fsys, err := OpenArchive(tgz)
+	checkPack(t, fsys, err)
+
+	other := filepath.Join(dir, "pack.rar")
+	os.WriteFile(other, []byte("x"), 0o644)
+	if _, err := OpenArchive(other); err == nil || !strings.Contains(err.Error(), "unsupported") {
+		t.Errorf("err = %v", err)
+	}
+}
+
+func TestArchiveTraversal(t *testing.T) {
+	for _, name := range []string{"../evil/SKILL.md", "pack/../../evil", "/etc/evil", `pack\..\evil`, "pack//x"} {
+		data := zipData(t, map[string]string{name: "x"})
+		if _, err := FromZip(bytes.NewReader(data), int64(len(data))); err == nil || !strings.Contains(err.Error(), "escapes") {
+			t.Errorf("zip %q: err = %v", name, err)
+		}
+		if _, err := FromTarGz(bytes.NewReader(tarGz(t, map[string]string{name: "x"}))); err == nil || !strings.Contains(err.Error(), "escapes") {
+			t.Errorf("tar %q: err = %v", name, err)
+		}
+	}
+	if _, err := FromTarGz(bytes.NewReader(tarGz(t, map[string]string{"a": "x", "a/b": "y"}))); err == nil {
+		t.Error("expected error for a file shadowing a directory")
+	}
+	if fsys, err := FromTarGz(bytes.NewReader(tarGz(t, map[string]string{"./pack/x.md": "x"}))); err != nil {
+		t.Error(err)
+	} else if _, err := fs.Stat(fsys, "pack/x.md"); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestFromGit(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	repo := t.TempDir()
+	git := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", append([]string{"-C", repo}, args...)...)
+		cmd.Env = append(os.Environ(), "GIT_AUTHOR_NAME=t", "GIT_AUTHOR_EMAIL=t@example.com", "GIT_COMMITTER_NAME=t", "GIT_COMMITTER_EMAIL=t@example.com", "GIT_CONFIG_GLOBAL=/dev/null")
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+	git("init", "-q")
+	for name, content := range packFiles {
+		p := filepath.Join(repo, "skills", filepath.FromSlash(name))
+		os.MkdirAll(filepath.Dir(p), 0o755)
+		os.WriteFile(p, []byte(content), 0o644)
+	}
+	git("add", ".")
+	git("commit", "-q", "-m", "v1")
+	git("tag", "v1")
+	os.WriteFile(filepath.Join(repo, "skills/pack/beta/SKILL.md"), []byte("---\nname: beta\nversion: 2.0.0\n---\n"), 0o644)
+	git("commit", "-q", "-am", "v2")
+
+	fsys, err := FromGit(repo, "v1", "skills")
+	checkPack(t, fsys, err)
+	if v := ListSkills(fsys)[1].Version; v != "" {
+		t.Errorf("v1 beta version = %q", v)
+	}
+	fsys, err = FromGit(repo, "HEAD", "/skills/pack/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := ListSkills(fsys); len(s) != 2 || s[1].Version != "2.0.0" {
+		t.Errorf("HEAD skills = %+v", s)
+	}
+	if _, err := FromGit(repo, "--output=/tmp/x", ""); err == nil {
+		t.Error("expected error for a ref that looks like a flag")
+	}
+	if _, err := FromGit(repo, "nope", ""); err == nil {
+		t.Error("expected error for an unknown ref")
+	}
+}
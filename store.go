@@ -0,0 +1,201 @@
+package instill
+
+import (
+	"fmt"
+	"io/fs"
+	"maps"
+	"path/filepath"
+	"slices"
+)
+
+// StoreDir is the canonical store Options.Symlink installs skills into,
+// relative to the project root, or to the home directory for global
+// installs. No built-in agent reads skills from it, so a skill in the store
+// is visible only to the agents linked to it.
+const StoreDir = ".agents/.store"
+
+// legacyStoreDir is where earlier versions kept the store. It is also the
+// skills directory of several agents, so links into it are still recognized
+// and released when they are replaced or removed.
+const legacyStoreDir = ".agents/skills"
+
+// storeDir returns the canonical store for the scope of opts.
+func storeDir(opts Options) string {
+	return scopeDir(StoreDir, opts)
+}
+
+// scopeDir returns rel relative to the home directory for global installs,
+// and to the project root otherwise.
+func scopeDir(rel string, opts Options) string {
+	if opts.Global {
+		return resolvePath("~/"+rel, "", true, opts.logger())
+	}
+	return filepath.Join(opts.ProjectDir, rel)
+}
+
+// linkedStore returns the store directory skillDir links to: the target of a
+// symlink into the store, or the store recorded in the manifest of a copy
+// made where a symlink could not be created. Links into the legacy store
+// are reported too. Links elsewhere are not, so that removing them never
+// touches what they point to.
+func linkedStore(skillDir string, opts Options) (string, bool) {
+	target, err := opts.targetFS().Readlink(skillDir)
+	if err != nil {
+		if target = readManifest(opts.targetFS(), skillDir, opts.logger()).Link; target == "" {
+			return "", false
+		}
+	}
+	if !filepath.IsAbs(target) {
+		target = filepath.Join(filepath.Dir(skillDir), target)
+	}
+	target = filepath.Clean(target)
+	dir := filepath.Dir(target)
+	return target, dir == filepath.Clean(storeDir(opts)) || dir == filepath.Clean(scopeDir(legacyStoreDir, opts))
+}
+
+// linkTarget returns what a link at skillDir to store contains: a relative
+// path for project installs, so the project can be moved or checked out
+// elsewhere, and an absolute one for global installs.
+func linkTarget(skillDir, store string, opts Options) string {
+	if !opts.Global {
+		if rel, err := filepath.Rel(filepath.Dir(skillDir), store); err == nil {
+			return rel
+		}
+	}
+	abs, err := filepath.Abs(store)
+	if err != nil {
+		return store
+	}
+	return abs
+}
+
+// linkSkill plans writing s once to the canonical store and linking the
+// skill directory of every target to it. Targets whose skills directory is
+// the store use it directly. Existing copies are replaced by links unless
+// they have local changes that opts.Conflict would preserve.
+func (pl *planner) linkSkill(s skillEntry, targets map[string][]string, signer string, opts Options) error {
+	store := filepath.Join(storeDir(opts), s.name)
+	_, statErr := pl.fs.Stat(store)
+	var prev manifest
+	if statErr == nil {
+		prev = readManifest(pl.fs, store, pl.log)
+	}
+	type link struct {
+		dir       string
+		agents    []string
+		existed   bool
+		prior     string
+		linked    bool // already a symlink to the store
+		conflicts []FileConflict
+	}
+	owners := prev.Agents
+	var direct []string
+	var links []link
+	for _, dir := range slices.Sorted(maps.Keys(targets)) {
+		agents := targets[dir]
+		owners = mergeOwners(owners, agents)
+		skillDir := filepath.Join(dir, s.name)
+		if skillDir == store {
+			direct = agents
+			continue
+		}
+		l := link{dir: skillDir, agents: agents, prior: installedVersionAt(pl.fs, skillDir)}
+		fi, err := pl.fs.Lstat(skillDir)
+		l.existed = err == nil
+		other, isLink := linkedStore(skillDir, opts)
+		switch {
+		case !l.existed:
+		case isLink && other == store:
+			l.linked = fi.Mode()&fs.ModeSymlink != 0
+		case isLink:
+			pl.release(other, agents)
+		case fi.IsDir():
+			m := readManifest(pl.fs, skillDir, pl.log)
+			conflicts, _, backups, err := resolveConflicts(pl.fs, skillDir, m, maps.Clone(s.files), opts.Conflict)
+			if err != nil {
+				return err
+			}
+			if (len(conflicts) > 0 || len(backups) > 0) && opts.Conflict != ConflictOverwrite {
+				return fmt.Errorf("instill: %s has local changes; linking it to %s would discard them", skillDir, store)
+			}
+			l.conflicts = conflicts
+			owners = mergeOwners(owners, m.Agents)
+		}
+		links = append(links, l)
+	}
+
+	files := maps.Clone(s.files)
+	conflicts, keep, backups, err := resolveConflicts(pl.fs, store, prev, files, opts.Conflict)
+	if err != nil {
+		return err
+	}
+	m := manifest{
+		Agents:    owners,
+		Commands:  sortedKeys(s.commands),
+		Subagents: sortedKeys(s.subagents),
+		Files:     hashFiles(s.files),
+		Signer:    signer,
+		Backups:   backups,
+	}
+	files[manifestName] = m.data()
+	pl.files(store, s.name, files, keep)
+	pl.kept(store, s.name, conflicts)
+
+	for _, an := range direct {
+		r := Result{Agent: an, Skill: s.name, Path: store, Existed: statErr == nil, PriorVersion: installedVersionAt(pl.fs, store), Owners: owners, Conflicts: conflicts}
+		pl.installed(s, r, opts)
+	}
+	for _, l := range links {
+		if !l.linked {
+			// The copy made if the link cannot be created records the store,
+			// so that it is updated and removed like a link.
+			fallback := m
+			fallback.Link = linkTarget(l.dir, store, opts)
+			pl.link(l.dir, fallback.Link, s.name, fallback.data())
+		}
+		for _, an := range l.agents {
+			r := Result{Agent: an, Skill: s.name, Path: l.dir, Link: store, Existed: l.existed, PriorVersion: l.prior, Owners: owners, Conflicts: slices.Concat(conflicts, l.conflicts)}
+			pl.installed(s, r, opts)
+		}
+	}
+	return nil
+}
+
+// link plans replacing p with a symlink to target. If the link cannot be
+// created, Apply copies the target directory instead and writes fallback as
+// the copy's manifest.
+func (pl *planner) link(p, target, skill string, fallback []byte) {
+	pl.mkdirAll(filepath.Dir(p), skill)
+	pl.plan.Ops = append(pl.plan.Ops, Op{Kind: OpSymlink, Path: p, Skill: skill, Link: target, Data: fallback})
+}
+
+// release plans dropping agents from the owners of a store directory. Once
+// no owners remain, the store directory is deleted; see releaseStores.
+func (pl *planner) release(store string, agents []string) {
+	if pl.released == nil {
+		pl.released = map[string][]string{}
+	}
+	pl.released[store] = mergeOwners(pl.released[store], agents)
+}
+
+// releaseStores plans the store changes collected by release.
+func (pl *planner) releaseStores() {
+	for _, store := range slices.Sorted(maps.Keys(pl.released)) {
+		if _, err := pl.fs.Stat(store); err != nil {
+			continue
+		}
+		m := readManifest(pl.fs, store, pl.log)
+		skill := filepath.Base(store)
+		remaining := slices.DeleteFunc(slices.Clone(m.Agents), func(a string) bool {
+			return slices.Contains(pl.released[store], a)
+		})
+		pl.root = store
+		if len(remaining) == 0 {
+			pl.delete(store, skill)
+		} else {
+			m.Agents = remaining
+			pl.write(filepath.Join(store, manifestName), skill, m.data())
+		}
+		pl.root = ""
+	}
+}
@@ -0,0 +1,261 @@
+package instill
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"testing/fstest"
+)
+
+var linkedSkills = fstest.MapFS{
+	"alpha/SKILL.md":         {Data: []byte("---\nname: alpha\nversion: 1.0.0\n---\n# Alpha\n")},
+	"alpha/references/a.md":  {Data: []byte("A\n")},
+	"alpha/_commands/run.md": {Data: []byte("Run!\n")},
+}
+
+func TestSymlinkInstall(t *testing.T) {
+	project := t.TempDir()
+	opts := Options{Agents: []string{"claude-code", "cursor", "windsurf"}, ProjectDir: project, Symlink: true}
+	results, err := Install(linkedSkills, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	store := filepath.Join(project, StoreDir, "alpha")
+	if len(results) != 3 {
+		t.Fatalf("got %d results, want 3", len(results))
+	}
+	for _, r := range results {
+		if r.Link != store {
+			t.Errorf("%s: Link = %q, want %q", r.Agent, r.Link, store)
+		}
+	}
+	for dir, want := range map[string]string{
+		".claude/skills/alpha":   filepath.Join("..", "..", ".agents", ".store", "alpha"),
+		".windsurf/skills/alpha": filepath.Join("..", "..", ".agents", ".store", "alpha"),
+		".agents/skills/alpha":   filepath.Join("..", ".store", "alpha"),
+	} {
+		target, err := os.Readlink(filepath.Join(project, dir))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if target != want {
+			t.Errorf("%s links to %q, want %q", dir, target, want)
+		}
+		if _, err := os.Stat(filepath.Join(project, dir, "references/a.md")); err != nil {
+			t.Error(err)
+		}
+	}
+	if got := readManifest(OSFS{}, store, discard).Agents; !slices.Equal(got, []string{"claude-code", "cursor", "windsurf"}) {
+		t.Errorf("store owners = %v", got)
+	}
+
+	installed, err := ListInstalled(Options{Agents: []string{"claude-code", "cursor"}, ProjectDir: project})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(installed) != 2 || installed[0].Link != store || installed[1].Link != store || !installed[1].Managed || len(installed[1].Owners) != 3 {
+		t.Errorf("ListInstalled = %+v", installed)
+	}
+
+	// Updating rewrites the store only.
+	updated := fstest.MapFS{"alpha/SKILL.md": {Data: []byte("---\nname: alpha\nversion: 1.1.0\n---\n")}}
+	plan, err := PlanInstall(updated, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, op := range plan.Ops {
+		if op.Kind == OpSymlink {
+			t.Errorf("update planned %s %s", op.Kind, op.Path)
+		}
+	}
+	if _, err := plan.Apply(); err != nil {
+		t.Fatal(err)
+	}
+	if v := installedVersionAt(OSFS{}, filepath.Join(project, ".claude/skills/alpha")); v != "1.1.0" {
+		t.Errorf("linked version = %q, want 1.1.0", v)
+	}
+}
+
+func TestSymlinkRemove(t *testing.T) {
+	project := t.TempDir()
+	opts := Options{Agents: []string{"claude-code", "cursor", "windsurf"}, ProjectDir: project, Symlink: true}
+	if _, err := Install(linkedSkills, opts); err != nil {
+		t.Fatal(err)
+	}
+	store := filepath.Join(project, StoreDir, "alpha")
+
+	results, err := Remove("alpha", Options{Agents: []string{"claude-code", "cursor"}, ProjectDir: project})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, r := range results {
+		if !r.Existed || len(r.Owners) != 0 {
+			t.Errorf("%s: %+v", r.Agent, r)
+		}
+	}
+	if _, err := os.Lstat(filepath.Join(project, ".claude/skills/alpha")); !os.IsNotExist(err) {
+		t.Errorf("claude-code link still exists: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(project, ".claude/commands/run.md")); !os.IsNotExist(err) {
+		t.Errorf("claude-code command still exists: %v", err)
+	}
+	if got := readManifest(OSFS{}, store, discard).Agents; !slices.Equal(got, []string{"windsurf"}) {
+		t.Errorf("store owners after removal = %v, want [windsurf]", got)
+	}
+
+	if _, err := Remove("alpha", Options{Agents: []string{"windsurf"}, ProjectDir: project}); err != nil {
+		t.Fatal(err)
+	}
+	for _, p := range []string{store, filepath.Join(project, ".windsurf/skills/alpha")} {
+		if _, err := os.Lstat(p); !os.IsNotExist(err) {
+			t.Errorf("%s still exists: %v", p, err)
+		}
+	}
+}
+
+func TestSymlinkFallback(t *testing.T) {
+	symlink = func(string, string) error { return errors.New("symlinks not permitted") }
+	t.Cleanup(func() { symlink = os.Symlink })
+
+	project := t.TempDir()
+	opts := Options{Agents: []string{"claude-code", "windsurf"}, ProjectDir: project, Symlink: true}
+	if _, err := Install(linkedSkills, opts); err != nil {
+		t.Fatal(err)
+	}
+	skillDir := filepath.Join(project, ".claude/skills/alpha")
+	fi, err := os.Lstat(skillDir)
+	if err != nil || !fi.IsDir() {
+		t.Fatalf("fallback copy: %v, %v", fi, err)
+	}
+	if _, err := os.Stat(filepath.Join(skillDir, "references/a.md")); err != nil {
+		t.Error(err)
+	}
+	store, ok := linkedStore(skillDir, opts)
+	if !ok || store != filepath.Join(project, StoreDir, "alpha") {
+		t.Errorf("linkedStore = %q, %v", store, ok)
+	}
+
+	// Once links work, the copy is replaced by a link.
+	symlink = os.Symlink
+	if _, err := Install(linkedSkills, opts); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Readlink(skillDir); err != nil {
+		t.Errorf("copy was not replaced by a link: %v", err)
+	}
+
+	if _, err := Remove("alpha", opts); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Lstat(store); !os.IsNotExist(err) {
+		t.Errorf("store still exists: %v", err)
+	}
+}
+
+func TestSymlinkReplacesCopy(t *testing.T) {
+	project := t.TempDir()
+	copyOpts := Options{Agents: []string{"claude-code"}, ProjectDir: project}
+	if _, err := Install(linkedSkills, copyOpts); err != nil {
+		t.Fatal(err)
+	}
+	skillDir := filepath.Join(project, ".claude/skills/alpha")
+	os.WriteFile(filepath.Join(skillDir, "notes.md"), []byte("mine\n"), 0o644)
+
+	linkOpts := copyOpts
+	linkOpts.Symlink, linkOpts.Conflict = true, ConflictKeep
+	if _, err := Install(linkedSkills, linkOpts); err == nil {
+		t.Fatal("linking over a copy with local changes succeeded with ConflictKeep")
+	}
+	linkOpts.Conflict = ConflictOverwrite
+	results, err := Install(linkedSkills, linkOpts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results[0].Conflicts) != 1 || results[0].Conflicts[0].File != "notes.md" {
+		t.Errorf("conflicts = %+v, want notes.md", results[0].Conflicts)
+	}
+	if _, err := os.Readlink(skillDir); err != nil {
+		t.Fatalf("copy was not replaced by a link: %v", err)
+	}
+
+	// Switching back to copies releases the store.
+	if _, err := Install(linkedSkills, copyOpts); err != nil {
+		t.Fatal(err)
+	}
+	if fi, err := os.Lstat(skillDir); err != nil || !fi.IsDir() {
+		t.Errorf("link was not replaced by a copy: %v, %v", fi, err)
+	}
+	if _, err := os.Lstat(filepath.Join(project, StoreDir, "alpha")); !os.IsNotExist(err) {
+		t.Errorf("released store still exists: %v", err)
+	}
+}
+
+func TestRemoveForeignLink(t *testing.T) {
+	project, elsewhere := t.TempDir(), t.TempDir()
+	writeTestSkill(t, elsewhere, "alpha", "---\nname: alpha\n---\n")
+	os.MkdirAll(filepath.Join(project, ".claude/skills"), 0o755)
+	if err := os.Symlink(filepath.Join(elsewhere, "alpha"), filepath.Join(project, ".claude/skills/alpha")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Remove("alpha", Options{Agents: []string{"claude-code"}, ProjectDir: project}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(elsewhere, "alpha/SKILL.md")); err != nil {
+		t.Errorf("removing a link deleted its target: %v", err)
+	}
+}
+
+func TestSymlinkVerify(t *testing.T) {
+	project := t.TempDir()
+	opts := Options{Agents: []string{"claude-code", "cursor"}, ProjectDir: project, Symlink: true, Lock: true}
+	if _, err := Install(linkedSkills, opts); err != nil {
+		t.Fatal(err)
+	}
+	drift, err := Verify(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(drift) != 0 {
+		t.Errorf("drift after install: %+v", drift)
+	}
+	os.WriteFile(filepath.Join(project, ".agents/skills/alpha/extra.md"), []byte("x\n"), 0o644)
+	drift, _ = Verify(opts)
+	if len(drift) != 2 || drift[0].Path != filepath.Join(project, ".claude/skills/alpha/extra.md") || drift[1].Path != filepath.Join(project, ".agents/skills/alpha/extra.md") {
+		t.Errorf("drift = %+v, want extra.md in the store and through the link", drift)
+	}
+}
+
+func TestSymlinkStoreIsPrivate(t *testing.T) {
+	project := t.TempDir()
+	if _, err := Install(linkedSkills, Options{Agents: []string{"claude-code"}, ProjectDir: project, Symlink: true}); err != nil {
+		t.Fatal(err)
+	}
+	installed, err := ListInstalled(Options{Agents: []string{"codex", "cursor", "gemini-cli"}, ProjectDir: project})
+	if err != nil || len(installed) != 0 {
+		t.Errorf("skill linked for claude-code is visible to other agents: %+v, %v", installed, err)
+	}
+}
+
+func TestSymlinkLegacyStore(t *testing.T) {
+	project := t.TempDir()
+	legacy := filepath.Join(project, legacyStoreDir, "alpha")
+	writeTestSkill(t, filepath.Dir(legacy), "alpha", "---\nname: alpha\nversion: 0.9.0\n---\n")
+	os.WriteFile(filepath.Join(legacy, manifestName), manifest{Agents: []string{"claude-code"}}.data(), 0o644)
+	os.MkdirAll(filepath.Join(project, ".claude/skills"), 0o755)
+	if err := os.Symlink(filepath.Join("..", "..", ".agents", "skills", "alpha"), filepath.Join(project, ".claude/skills/alpha")); err != nil {
+		t.Fatal(err)
+	}
+
+	opts := Options{Agents: []string{"claude-code"}, ProjectDir: project, Symlink: true}
+	if _, err := Install(linkedSkills, opts); err != nil {
+		t.Fatal(err)
+	}
+	if target, _ := os.Readlink(filepath.Join(project, ".claude/skills/alpha")); target != filepath.Join("..", "..", ".agents", ".store", "alpha") {
+		t.Errorf("link = %q, want the new store", target)
+	}
+	if _, err := os.Lstat(legacy); !os.IsNotExist(err) {
+		t.Errorf("released legacy store still exists: %v", err)
+	}
+}
@@ -0,0 +1,246 @@
+package instill
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// ConfigFile is the project config that Sync reads from the project root.
+const ConfigFile = "instill.json"
+
+// ProjectConfig is the desired state of a project, usually committed as
+// instill.json:
+//
+//	{"agents": ["claude-code", "cursor"], "skills": ["my-skill"]}
+type ProjectConfig struct {
+	Agents []string `json:"agents"`
+	Skills []string `json:"skills,omitempty"` // empty means every skill Sync is given
+}
+
+// LoadProjectConfig reads ConfigFile from projectDir. The error wraps
+// fs.ErrNotExist if the project has no config.
+func LoadProjectConfig(projectDir string) (ProjectConfig, error) {
+	var cfg ProjectConfig
+	p := filepath.Join(projectDir, ConfigFile)
+	data, err := os.ReadFile(p)
+	if err != nil {
+		return cfg, fmt.Errorf("instill: reading project config: %w", err)
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&cfg); err != nil {
+		return cfg, fmt.Errorf("instill: parsing %s: %w", p, err)
+	}
+	return cfg, nil
+}
+
+// SyncAction is what Sync does to one skill for one agent.
+type SyncAction int
+
+const (
+	SyncKeep    SyncAction = iota // already up to date, or newer than bundled
+	SyncInstall                   // missing, installed
+	SyncUpdate                    // outdated, reinstalled
+	SyncRemove                    // installed by instill but no longer listed, removed
+)
+
+var syncActionNames = [...]string{"keep", "install", "update", "remove"}
+
+func (a SyncAction) String() string {
+	if int(a) < len(syncActionNames) {
+		return syncActionNames[a]
+	}
+	return fmt.Sprintf("SyncAction(%d)", int(a))
+}
+
+func (a SyncAction) MarshalText() ([]byte, error) { return []byte(a.String()), nil }
+
+// SyncChange summarizes Sync's decision for one skill and agent.
+type SyncChange struct {
+	Skill  string
+	Agent  string
+	Action SyncAction
+	From   string // installed version before the sync ("" if missing or unversioned)
+	To     string // version after the sync ("" if removed or unversioned)
+}
+
+// SyncPlan is a Plan that converges a project to its config, with a summary
+// of the changes it makes.
+type SyncPlan struct {
+	Plan
+	Changes []SyncChange // sorted by skill, then agent
+}
+
+// PlanSync computes the changes that bring the skill directories of the
+// project in opts.ProjectDir to the state described by its ConfigFile: listed
+// skills from fsys are installed for every listed agent, outdated copies are
+// updated, and skills instill installed that are no longer listed are removed.
+// In project scope, so are the skills of agents dropped from the config, unless
+// a listed agent shares their directory and still wants the skill. Skills
+// installed by other tools are never removed, and copies newer than the
+// bundled skill are kept. The config replaces opts.Agents and opts.Skills;
+// other options apply as for Install.
+func PlanSync(fsys fs.FS, opts Options) (*SyncPlan, error) {
+	cfg, err := LoadProjectConfig(opts.ProjectDir)
+	if err != nil {
+		return nil, err
+	}
+	if len(cfg.Agents) == 0 {
+		return nil, fmt.Errorf("instill: %s lists no agents", ConfigFile)
+	}
+	skills, err := findSkills(fsys)
+	if err != nil {
+		return nil, err
+	}
+	bundled := make([]string, len(skills))
+	for i, s := range skills {
+		bundled[i] = s.name
+	}
+	wanted := cfg.Skills
+	if len(wanted) == 0 {
+		wanted = bundled
+	}
+	for _, name := range wanted {
+		if !slices.Contains(bundled, name) {
+			return nil, fmt.Errorf("instill: skill %q listed in %s not found", name, ConfigFile)
+		}
+	}
+	opts.Agents, opts.Skills = cfg.Agents, wanted
+
+	sp := &SyncPlan{}
+	updates, err := CheckUpdates(fsys, opts)
+	if err != nil {
+		return nil, err
+	}
+	pending := map[string][]string{} // skill → agents to install it for
+	var order []string
+	for _, u := range updates {
+		c := SyncChange{Skill: u.Skill, Agent: u.Agent, From: u.Installed, To: u.Installed}
+		switch {
+		case u.Status == UpdateMissing:
+			c.Action, c.To = SyncInstall, u.Bundled
+		case u.Outdated():
+			c.Action, c.To = SyncUpdate, u.Bundled
+		}
+		sp.Changes = append(sp.Changes, c)
+		if c.Action == SyncKeep {
+			continue
+		}
+		if _, ok := pending[u.Skill]; !ok {
+			order = append(order, u.Skill)
+		}
+		pending[u.Skill] = append(pending[u.Skill], u.Agent)
+	}
+	for _, name := range order {
+		one := opts
+		one.Agents, one.Skills = pending[name], []string{name}
+		p, err := PlanInstall(fsys, one)
+		if err != nil {
+			return nil, err
+		}
+		if err := sp.Append(p); err != nil {
+			return nil, err
+		}
+	}
+
+	_, installed, err := syncAgents(cfg, opts)
+	if err != nil {
+		return nil, err
+	}
+	for _, s := range installed {
+		isWanted := slices.Contains(wanted, s.Name)
+		shared := slices.ContainsFunc(s.Agents, func(a string) bool { return slices.Contains(cfg.Agents, a) })
+		var agents []string
+		for _, a := range s.Agents {
+			listed := slices.Contains(cfg.Agents, a)
+			if slices.Contains(s.Owners, a) && (listed && !isWanted || !listed && !(isWanted && shared)) {
+				agents = append(agents, a)
+			}
+		}
+		if len(agents) == 0 {
+			continue
+		}
+		one := opts
+		one.Agents = agents
+		p, err := PlanRemove(s.Name, one)
+		if err != nil {
+			return nil, err
+		}
+		if err := sp.Append(p); err != nil {
+			return nil, err
+		}
+		for _, a := range agents {
+			sp.Changes = append(sp.Changes, SyncChange{Skill: s.Name, Agent: a, Action: SyncRemove, From: s.Meta.Version})
+		}
+	}
+	slices.SortStableFunc(sp.Changes, func(a, b SyncChange) int {
+		return cmp.Or(strings.Compare(a.Skill, b.Skill), strings.Compare(a.Agent, b.Agent))
+	})
+	return sp, nil
+}
+
+// syncAgents returns the agents whose skills directories PlanSync may change
+// and the managed skills installed there in the scope of opts: the agents cfg
+// lists and, in project scope, those recorded as owners of skills instill
+// installed in the project.
+func syncAgents(cfg ProjectConfig, opts Options) ([]string, []InstalledSkill, error) {
+	scope := ScopeProject
+	agents := cfg.Agents
+	if opts.Global {
+		scope = ScopeGlobal
+	} else {
+		agents = nil // every agent, to find those dropped from cfg
+	}
+	all, err := ListInstalled(Options{Agents: agents, ProjectDir: opts.ProjectDir, FS: opts.FS})
+	if err != nil {
+		return nil, nil, err
+	}
+	names := slices.Clone(cfg.Agents)
+	var installed []InstalledSkill
+	for _, s := range all {
+		if s.Scope != scope || !s.Managed {
+			continue
+		}
+		installed = append(installed, s)
+		for _, a := range s.Agents {
+			if slices.Contains(s.Owners, a) && !slices.Contains(names, a) {
+				names = append(names, a)
+			}
+		}
+	}
+	return names, installed, nil
+}
+
+// Sync converges the project in opts.ProjectDir to its ConfigFile; see
+// PlanSync. Either every change is applied or none is.
+func Sync(fsys fs.FS, opts Options) ([]SyncChange, error) {
+	cfg, err := LoadProjectConfig(opts.ProjectDir)
+	if err != nil {
+		return nil, err
+	}
+	locked := opts
+	if locked.Agents, _, err = syncAgents(cfg, opts); err != nil {
+		return nil, err
+	}
+	unlock, err := lockTargets(context.Background(), locked)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+	sp, err := PlanSync(fsys, opts)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := sp.apply(context.Background()); err != nil {
+		return nil, err
+	}
+	return sp.Changes, nil
+}
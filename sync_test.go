@@ -0,0 +1,171 @@
+package instill
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func writeConfig(t *testing.T, project, data string) {
+	t.Helper()
+	if err := os.WriteFile(filepath.Join(project, ConfigFile), []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func twoSkills(version string) fstest.MapFS {
+	fsys := fstest.MapFS{}
+	for _, name := range []string{"alpha", "beta"} {
+		fsys["skills/"+name+"/SKILL.md"] = &fstest.MapFile{Data: []byte("---\nname: " + name + "\nversion: " + version + "\n---\n")}
+	}
+	return fsys
+}
+
+func actions(changes []SyncChange) []string {
+	var out []string
+	for _, c := range changes {
+		out = append(out, c.Skill+" "+c.Agent+" "+c.Action.String())
+	}
+	return out
+}
+
+func TestSync(t *testing.T) {
+	project := t.TempDir()
+	opts := Options{ProjectDir: project}
+
+	writeConfig(t, project, `{"agents": ["claude-code", "cursor"], "skills": ["alpha", "beta"]}`)
+	changes, err := Sync(twoSkills("1.0.0"), opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"alpha claude-code install", "alpha cursor install", "beta claude-code install", "beta cursor install"}
+	if got := actions(changes); !slices.Equal(got, want) {
+		t.Errorf("first sync = %v, want %v", got, want)
+	}
+
+	// Nothing to do the second time.
+	sp, err := PlanSync(twoSkills("1.0.0"), opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sp.Ops) != 0 || slices.ContainsFunc(sp.Changes, func(c SyncChange) bool { return c.Action != SyncKeep }) {
+		t.Errorf("second sync should be a no-op: %v, %d ops", actions(sp.Changes), len(sp.Ops))
+	}
+
+	// A skill installed by another tool must survive, even when unlisted.
+	manual := filepath.Join(project, ".claude/skills/manual")
+	os.MkdirAll(manual, 0o755)
+	os.WriteFile(filepath.Join(manual, "SKILL.md"), []byte("---\nname: manual\n---\n"), 0o644)
+
+	writeConfig(t, project, `{"agents": ["claude-code", "cursor"], "skills": ["alpha"]}`)
+	changes, err = Sync(twoSkills("1.1.0"), opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want = []string{"alpha claude-code update", "alpha cursor update", "beta claude-code remove", "beta cursor remove"}
+	if got := actions(changes); !slices.Equal(got, want) {
+		t.Errorf("second sync = %v, want %v", got, want)
+	}
+	if changes[0].From != "1.0.0" || changes[0].To != "1.1.0" || changes[2].From != "1.0.0" {
+		t.Errorf("versions = %+v", changes)
+	}
+	for _, p := range []string{".claude/skills/beta", ".agents/skills/beta"} {
+		if _, err := os.Stat(filepath.Join(project, p)); !os.IsNotExist(err) {
+			t.Errorf("%s should be removed", p)
+		}
+	}
+	if _, err := os.Stat(manual); err != nil {
+		t.Error("unmanaged skill was removed")
+	}
+	if v := installedVersionAt(OSFS{}, filepath.Join(project, ".claude/skills/alpha")); v != "1.1.0" {
+		t.Errorf("alpha version = %q", v)
+	}
+}
+
+func TestSyncSharedOwner(t *testing.T) {
+	project := t.TempDir()
+	// codex shares .agents/skills with cursor but is not in the config.
+	if _, err := Install(skillFS("alpha"), Options{Agents: []string{"cursor", "codex"}, ProjectDir: project}); err != nil {
+		t.Fatal(err)
+	}
+	writeConfig(t, project, `{"agents": ["cursor"], "skills": ["alpha"]}`)
+	changes, err := Sync(twoSkills("1.0.0"), Options{ProjectDir: project})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"alpha cursor update"}
+	if got := actions(changes); !slices.Equal(got, want) {
+		t.Errorf("sync = %v, want %v", got, want)
+	}
+	m := readManifest(OSFS{}, filepath.Join(project, ".agents/skills/alpha"), discard)
+	if !slices.Equal(m.Agents, []string{"codex", "cursor"}) {
+		t.Errorf("alpha should be shared with codex, owners = %v", m.Agents)
+	}
+
+	// Once cursor no longer wants it, nothing listed needs the directory.
+	writeConfig(t, project, `{"agents": ["cursor"], "skills": ["beta"]}`)
+	changes, err = Sync(twoSkills("1.0.0"), Options{ProjectDir: project})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want = []string{"alpha codex remove", "alpha cursor remove", "beta cursor install"}
+	if got := actions(changes); !slices.Equal(got, want) {
+		t.Errorf("sync = %v, want %v", got, want)
+	}
+	if _, err := os.Stat(filepath.Join(project, ".agents/skills/alpha")); !os.IsNotExist(err) {
+		t.Error("alpha should be removed")
+	}
+}
+
+func TestSyncDroppedAgent(t *testing.T) {
+	project := t.TempDir()
+	opts := Options{ProjectDir: project}
+	writeConfig(t, project, `{"agents": ["claude-code", "windsurf"]}`)
+	if _, err := Sync(twoSkills("1.0.0"), opts); err != nil {
+		t.Fatal(err)
+	}
+
+	writeConfig(t, project, `{"agents": ["claude-code"]}`)
+	changes, err := Sync(twoSkills("1.0.0"), opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"alpha claude-code keep", "alpha windsurf remove", "beta claude-code keep", "beta windsurf remove"}
+	if got := actions(changes); !slices.Equal(got, want) {
+		t.Errorf("sync = %v, want %v", got, want)
+	}
+	for _, p := range []string{".windsurf/skills/alpha", ".windsurf/skills/beta"} {
+		if _, err := os.Stat(filepath.Join(project, p)); !os.IsNotExist(err) {
+			t.Errorf("%s should be removed", p)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(project, ".claude/skills/alpha/SKILL.md")); err != nil {
+		t.Error(err)
+	}
+	if sp, err := PlanSync(twoSkills("1.0.0"), opts); err != nil || len(sp.Ops) != 0 {
+		t.Errorf("sync after dropping an agent should converge: %d ops, %v", len(sp.Ops), err)
+	}
+}
+
+func TestSyncErrors(t *testing.T) {
+	project := t.TempDir()
+	if _, err := Sync(twoSkills("1.0.0"), Options{ProjectDir: project}); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("missing config: err = %v", err)
+	}
+	for cfg, want := range map[string]string{
+		`{"agents": []}`: "lists no agents",
+		`{"agents": ["cursor"], "skills": ["x"]}`: `skill "x" listed`,
+		`{"agents": ["cursor"], "skils": []}`:     "unknown field",
+		`{"agents": ["nope"]}`:                    "unknown agent",
+	} {
+		writeConfig(t, project, cfg)
+		if _, err := Sync(twoSkills("1.0.0"), Options{ProjectDir: project}); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("%s: err = %v, want %q", cfg, err, want)
+		}
+	}
+}
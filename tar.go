@@ -0,0 +1,150 @@
+package instill
+
+import (
+	"archive/tar"
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// TarOptions configure the archive written by InstallTar.
+type TarOptions struct {
+	// Home is the home directory in the image the archive is extracted
+	// into. Paths under this process's home directory, such as global
+	// skills directories, are moved under it. Empty keeps them as they are.
+	Home string
+
+	Uid, Gid     int    // owner of every entry; zero is root
+	Uname, Gname string // owner names, if the image has them
+
+	FileMode fs.FileMode // permissions of files; zero keeps 0644
+	DirMode  fs.FileMode // permissions of directories; zero keeps 0755
+
+	// ModTime is the modification time of every entry. Zero means the Unix
+	// epoch, so that archives of the same skills are byte-for-byte equal.
+	ModTime time.Time
+}
+
+// InstallTar installs skills from fsys as Install does, but into a tar
+// archive written to w instead of the local disk, for adding to a container
+// image. Options.FS is ignored: the install starts from an empty tree, so the
+// archive holds every skill, command, subagent, store link and lockfile the
+// agents need. Absolute paths are stored relative to the root of the image.
+//
+// The project directory, the home directory and their parents are left out
+// of the archive, so extracting it does not change their owner or mode.
+// Result paths are those in the image.
+func InstallTar(w io.Writer, fsys fs.FS, opts Options, t TarOptions) ([]Result, error) {
+	return InstallTarContext(context.Background(), w, fsys, opts, t)
+}
+
+// InstallTarContext is InstallTar with a context. If ctx is cancelled before
+// the install completes, nothing is written.
+func InstallTarContext(ctx context.Context, w io.Writer, fsys fs.FS, opts Options, t TarOptions) ([]Result, error) {
+	mem := NewMemFS()
+	opts.FS = mem
+	results, err := InstallContext(ctx, fsys, opts)
+	if err != nil && !opts.ContinueOnError {
+		return nil, err
+	}
+	home, _ := os.UserHomeDir()
+	paths := tarPaths{home: home, image: t.Home, keep: []string{filepath.Clean(opts.ProjectDir)}}
+	if home != "" {
+		paths.keep = append(paths.keep, home)
+	}
+	if werr := writeTar(w, mem, paths, t); werr != nil {
+		return nil, fmt.Errorf("instill: writing archive: %w", werr)
+	}
+	for i := range results {
+		results[i].Path = paths.rebase(results[i].Path)
+		if results[i].Link != "" {
+			results[i].Link = paths.rebase(results[i].Link)
+		}
+	}
+	return results, err
+}
+
+// tarPaths maps paths of an install to entries of its archive.
+type tarPaths struct {
+	home  string   // local home directory
+	image string   // TarOptions.Home
+	keep  []string // directories whose own entries, and their parents', are left out
+}
+
+// rebase moves p from the local home directory to the image's.
+func (tp tarPaths) rebase(p string) string {
+	if tp.image == "" || tp.home == "" || !hasPathPrefix(p, tp.home) {
+		return p
+	}
+	return filepath.Join(tp.image, p[len(tp.home):])
+}
+
+// name returns the archive entry name of p.
+func (tp tarPaths) name(p string) string {
+	p = tp.rebase(p)
+	return strings.TrimPrefix(filepath.ToSlash(p[len(filepath.VolumeName(p)):]), "/")
+}
+
+// skip reports whether the directory p is left out of the archive.
+func (tp tarPaths) skip(p string) bool {
+	return slices.ContainsFunc(tp.keep, func(k string) bool { return hasPathPrefix(k, p) })
+}
+
+// writeTar writes the contents of m to w as a tar archive, parents first.
+func writeTar(w io.Writer, m *MemFS, paths tarPaths, t TarOptions) error {
+	mtime := t.ModTime
+	if mtime.IsZero() {
+		mtime = time.Unix(0, 0)
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	tw := tar.NewWriter(w)
+	for _, p := range slices.Sorted(maps.Keys(m.nodes)) {
+		n := m.nodes[p]
+		hdr := &tar.Header{
+			Name:    paths.name(p),
+			Mode:    int64(n.mode.Perm()),
+			ModTime: mtime,
+			Uid:     t.Uid,
+			Gid:     t.Gid,
+			Uname:   t.Uname,
+			Gname:   t.Gname,
+		}
+		switch {
+		case n.mode.IsDir():
+			if paths.skip(p) {
+				continue
+			}
+			hdr.Typeflag, hdr.Name = tar.TypeDir, hdr.Name+"/"
+			if t.DirMode != 0 {
+				hdr.Mode = int64(t.DirMode.Perm())
+			}
+		case n.mode&fs.ModeSymlink != 0:
+			hdr.Typeflag, hdr.Linkname = tar.TypeSymlink, filepath.ToSlash(n.link)
+			if filepath.IsAbs(n.link) {
+				hdr.Linkname = "/" + paths.name(n.link)
+			}
+		default:
+			hdr.Typeflag, hdr.Size = tar.TypeReg, int64(len(n.data))
+			if t.FileMode != 0 {
+				hdr.Mode = int64(t.FileMode.Perm())
+			}
+		}
+		if err := tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+		if hdr.Typeflag == tar.TypeReg {
+			if _, err := tw.Write(n.data); err != nil {
+				return err
+			}
+		}
+	}
+	return tw.Close()
+}
@@ -0,0 +1,102 @@
+package instill
+
+import (
+	"archive/tar"
+	"bytes"
+	"errors"
+	"io"
+	"io/fs"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// readTar returns the headers of an archive by name, with each file's content
+// in its Linkname field.
+func readTar(t *testing.T, data []byte) map[string]*tar.Header {
+	t.Helper()
+	out := map[string]*tar.Header{}
+	tr := tar.NewReader(bytes.NewReader(data))
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return out
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		if hdr.Typeflag == tar.TypeReg {
+			content, _ := io.ReadAll(tr)
+			hdr.Linkname = string(content)
+		}
+		out[hdr.Name] = hdr
+	}
+}
+
+func TestInstallTar(t *testing.T) {
+	var buf bytes.Buffer
+	opts := Options{Agents: []string{"claude-code", "windsurf"}, ProjectDir: "/workspaces/app", Symlink: true, Lock: true}
+	tarOpts := TarOptions{Uid: 1000, Gid: 1000, Uname: "dev", FileMode: 0o640}
+	results, err := InstallTar(&buf, linkedSkills, opts, tarOpts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 2 || results[0].Path != "/workspaces/app/.claude/skills/alpha" {
+		t.Errorf("results = %+v", results)
+	}
+	entries := readTar(t, buf.Bytes())
+	for _, name := range []string{"workspaces/", "workspaces/app/"} {
+		if _, ok := entries[name]; ok {
+			t.Errorf("archive has %s", name)
+		}
+	}
+	skill := entries["workspaces/app/.agents/.store/alpha/SKILL.md"]
+	if skill == nil || skill.Mode != 0o640 || skill.Uid != 1000 || skill.Uname != "dev" || !skill.ModTime.Equal(time.Unix(0, 0)) {
+		t.Fatalf("SKILL.md entry = %+v", skill)
+	}
+	if dir := entries["workspaces/app/.agents/.store/alpha/"]; dir == nil || dir.Typeflag != tar.TypeDir || dir.Mode != 0o755 {
+		t.Errorf("skill directory entry = %+v", dir)
+	}
+	link := entries["workspaces/app/.claude/skills/alpha"]
+	if link == nil || link.Typeflag != tar.TypeSymlink || link.Linkname != "../../.agents/.store/alpha" {
+		t.Errorf("link entry = %+v", link)
+	}
+	for _, name := range []string{"workspaces/app/.claude/commands/run.md", "workspaces/app/" + LockFile} {
+		if entries[name] == nil {
+			t.Errorf("archive lacks %s", name)
+		}
+	}
+
+	var again bytes.Buffer
+	if _, err := InstallTar(&again, linkedSkills, opts, tarOpts); err != nil || !bytes.Equal(buf.Bytes(), again.Bytes()) {
+		t.Errorf("archives differ between runs (err %v)", err)
+	}
+}
+
+func TestInstallTarGlobal(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home)
+	t.Setenv("CLAUDE_CONFIG_DIR", "")
+	var buf bytes.Buffer
+	results, err := InstallTar(&buf, skillFS("my-skill"), Options{Agents: []string{"claude-code"}, Global: true}, TarOptions{Home: "/home/dev", DirMode: 0o700})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := filepath.FromSlash("/home/dev/.claude/skills/my-skill"); len(results) != 1 || results[0].Path != want {
+		t.Errorf("results = %+v, want path %s", results, want)
+	}
+	entries := readTar(t, buf.Bytes())
+	if entries["home/dev/.claude/skills/my-skill/SKILL.md"] == nil {
+		t.Errorf("archive = %v", entries)
+	}
+	if dir := entries["home/dev/.claude/"]; dir == nil || fs.FileMode(dir.Mode) != 0o700 {
+		t.Errorf(".claude entry = %+v", dir)
+	}
+	if _, ok := entries["home/dev/"]; ok {
+		t.Error("archive has the home directory")
+	}
+	if installed, _ := ListInstalled(Options{Agents: []string{"claude-code"}, Global: true}); len(installed) != 0 {
+		t.Errorf("install wrote to disk: %+v", installed)
+	}
+}
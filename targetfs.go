@@ -0,0 +1,132 @@
+package instill
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// TargetFS is the file system skills are installed into, removed from and
+// detected in. Paths are native, as for the os package; implementations
+// decide what they refer to, such as a sandbox, an image layer or memory.
+//
+// Rename must replace an existing file, symlink or empty directory at newpath,
+// as os.Rename does on Unix, for Plan.Apply to stay atomic. Stat, ReadFile,
+// ReadDir, WriteFile and Chmod follow symlinks; Lstat, Readlink, Rename and
+// RemoveAll do not.
+type TargetFS interface {
+	Stat(name string) (fs.FileInfo, error)
+	Lstat(name string) (fs.FileInfo, error)
+	ReadFile(name string) ([]byte, error)
+	ReadDir(name string) ([]fs.DirEntry, error)
+	Readlink(name string) (string, error)
+	MkdirAll(name string, perm fs.FileMode) error
+	WriteFile(name string, data []byte, perm fs.FileMode) error
+	Chmod(name string, mode fs.FileMode) error
+	Symlink(oldname, newname string) error
+	Rename(oldpath, newpath string) error
+	RemoveAll(name string) error
+}
+
+// OSFS is the TargetFS of the local disk, used when Options.FS is nil.
+type OSFS struct{}
+
+func (OSFS) Stat(name string) (fs.FileInfo, error)      { return os.Stat(name) }
+func (OSFS) Lstat(name string) (fs.FileInfo, error)     { return os.Lstat(name) }
+func (OSFS) ReadFile(name string) ([]byte, error)       { return os.ReadFile(name) }
+func (OSFS) ReadDir(name string) ([]fs.DirEntry, error) { return os.ReadDir(name) }
+func (OSFS) Readlink(name string) (string, error)       { return os.Readlink(name) }
+func (OSFS) MkdirAll(name string, perm fs.FileMode) error {
+	return os.MkdirAll(name, perm)
+}
+func (OSFS) WriteFile(name string, data []byte, perm fs.FileMode) error {
+	return os.WriteFile(name, data, perm)
+}
+func (OSFS) Chmod(name string, mode fs.FileMode) error { return os.Chmod(name, mode) }
+func (OSFS) Symlink(oldname, newname string) error     { return symlink(oldname, newname) }
+func (OSFS) Rename(oldpath, newpath string) error      { return os.Rename(oldpath, newpath) }
+func (OSFS) RemoveAll(name string) error               { return os.RemoveAll(name) }
+
+// symlink creates symbolic links for OSFS; tests replace it to simulate
+// systems where links cannot be created.
+var symlink = os.Symlink
+
+// targetFS returns the TargetFS of opts.
+func (o Options) targetFS() TargetFS {
+	if o.FS != nil {
+		return o.FS
+	}
+	return OSFS{}
+}
+
+// isOS reports whether fsys is the local disk.
+func isOS(fsys TargetFS) bool {
+	_, ok := fsys.(OSFS)
+	return ok
+}
+
+// tempName returns an unused path next to p for staging or displacing it.
+func tempName(fsys TargetFS, p, suffix string) (string, error) {
+	for range 10 {
+		var b [6]byte
+		if _, err := rand.Read(b[:]); err != nil {
+			return "", err
+		}
+		name := filepath.Join(filepath.Dir(p), "."+filepath.Base(p)+suffix+hex.EncodeToString(b[:]))
+		if _, err := fsys.Lstat(name); errors.Is(err, fs.ErrNotExist) {
+			return name, nil
+		}
+	}
+	return "", &fs.PathError{Op: "tempname", Path: p, Err: fs.ErrExist}
+}
+
+// walkDir walks the tree at root in fsys like filepath.WalkDir, except that
+// a root that is a symlink to a directory is walked through.
+func walkDir(fsys TargetFS, root string, fn fs.WalkDirFunc) error {
+	info, err := fsys.Stat(root)
+	if err != nil {
+		err = fn(root, nil, err)
+	} else {
+		err = walk(fsys, root, fs.FileInfoToDirEntry(info), fn)
+	}
+	if errors.Is(err, fs.SkipDir) || errors.Is(err, fs.SkipAll) {
+		return nil
+	}
+	return err
+}
+
+func walk(fsys TargetFS, p string, d fs.DirEntry, fn fs.WalkDirFunc) error {
+	if err := fn(p, d, nil); err != nil || !d.IsDir() {
+		if err == fs.SkipDir && d.IsDir() {
+			err = nil
+		}
+		return err
+	}
+	entries, err := fsys.ReadDir(p)
+	if err != nil {
+		if err = fn(p, d, err); err != nil {
+			if err == fs.SkipDir && d.IsDir() {
+				err = nil
+			}
+			return err
+		}
+	}
+	for _, e := range entries {
+		if err := walk(fsys, filepath.Join(p, e.Name()), e, fn); err != nil {
+			if err == fs.SkipDir {
+				break
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+// hasPathPrefix reports whether p is dir or inside it.
+func hasPathPrefix(p, dir string) bool {
+	return p == dir || strings.HasPrefix(p, strings.TrimSuffix(dir, string(filepath.Separator))+string(filepath.Separator))
+}
@@ -0,0 +1,119 @@
+package instill
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestInstallMemFS(t *testing.T) {
+	project := t.TempDir()
+	mem := NewMemFS()
+	opts := Options{Agents: []string{"claude-code", "cursor", "windsurf"}, ProjectDir: project, Symlink: true, Lock: true, FS: mem}
+	if _, err := Install(linkedSkills, opts); err != nil {
+		t.Fatal(err)
+	}
+	if entries, _ := os.ReadDir(project); len(entries) != 0 {
+		t.Errorf("install wrote %d entries to disk", len(entries))
+	}
+
+	link := filepath.Join(project, ".claude/skills/alpha")
+	if target, err := mem.Readlink(link); err != nil || target != filepath.Join("..", "..", ".agents", ".store", "alpha") {
+		t.Errorf("Readlink = %q, %v", target, err)
+	}
+	if data, err := mem.ReadFile(filepath.Join(link, "references/a.md")); err != nil || string(data) != "A\n" {
+		t.Errorf("reading through link = %q, %v", data, err)
+	}
+	if _, err := mem.Stat(filepath.Join(project, ".claude/commands/run.md")); err != nil {
+		t.Error(err)
+	}
+
+	installed, err := ListInstalled(Options{Agents: []string{"claude-code"}, ProjectDir: project, FS: mem})
+	if err != nil || len(installed) != 1 || installed[0].Meta.Version != "1.0.0" || !installed[0].Managed {
+		t.Errorf("ListInstalled = %+v, %v", installed, err)
+	}
+	if drift, err := Verify(Options{ProjectDir: project, FS: mem}); err != nil || len(drift) != 0 {
+		t.Errorf("Verify = %+v, %v", drift, err)
+	}
+
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home)
+	t.Setenv("CLAUDE_CONFIG_DIR", filepath.Join(home, ".claude"))
+	mem.MkdirAll(filepath.Join(home, ".claude"), 0o755)
+	detected := func(agents []Agent, err error) bool {
+		if err != nil {
+			t.Fatal(err)
+		}
+		return slices.ContainsFunc(agents, func(a Agent) bool { return a.Name == "claude-code" })
+	}
+	if !detected(DetectFS(context.Background(), mem, project, false)) {
+		t.Error("DetectFS did not find claude-code")
+	}
+	if detected(Detect(project, false)) {
+		t.Error("Detect found claude-code on disk")
+	}
+
+	if _, err := Remove("alpha", opts); err != nil {
+		t.Fatal(err)
+	}
+	for _, p := range []string{link, filepath.Join(project, StoreDir, "alpha")} {
+		if _, err := mem.Lstat(p); !errors.Is(err, fs.ErrNotExist) {
+			t.Errorf("%s after remove: %v", p, err)
+		}
+	}
+}
+
+func TestMemFS(t *testing.T) {
+	m := NewMemFS()
+	if err := m.WriteFile("/a/f", nil, 0o644); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("write without parent: %v", err)
+	}
+	if err := m.MkdirAll("/a/b", 0o755); err != nil {
+		t.Fatal(err)
+	}
+	m.WriteFile("/a/b/f", []byte("1"), 0o644)
+	m.WriteFile("/a/g", []byte("2"), 0o600)
+	m.MkdirAll("/c", 0o755)
+
+	// Renaming over an empty directory replaces it; over a full one fails.
+	if err := m.Rename("/a", "/c"); err != nil {
+		t.Fatal(err)
+	}
+	if data, err := m.ReadFile("/c/b/f"); err != nil || string(data) != "1" {
+		t.Errorf("moved file = %q, %v", data, err)
+	}
+	m.MkdirAll("/d/e", 0o755)
+	if err := m.Rename("/c", "/d"); err == nil {
+		t.Error("rename over non-empty directory succeeded")
+	}
+	if err := m.Rename("/c/g", "/c/b/f"); err != nil {
+		t.Fatal(err)
+	}
+	if info, err := m.Stat("/c/b/f"); err != nil || info.Mode() != 0o600 {
+		t.Errorf("replaced file = %v, %v", info, err)
+	}
+
+	m.Symlink("c/b", "/l")
+	m.Symlink("/loop", "/loop")
+	if entries, err := m.ReadDir("/l"); err != nil || len(entries) != 1 || entries[0].Name() != "f" {
+		t.Errorf("ReadDir through link = %v, %v", entries, err)
+	}
+	if _, err := m.Stat("/loop"); err == nil || errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("symlink loop: %v", err)
+	}
+	if err := m.RemoveAll("/l"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.Stat("/c/b/f"); err != nil {
+		t.Errorf("removing a link removed its target: %v", err)
+	}
+	m.RemoveAll("/c")
+	if entries, _ := m.ReadDir("/"); len(entries) != 2 {
+		t.Errorf("root = %v", entries)
+	}
+}
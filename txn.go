@@ -0,0 +1,342 @@
+package instill
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"path/filepath"
+)
+
+// transaction applies a plan's operations so that either all of them take
+// effect or none do. Skill directories are rebuilt in a staging directory next
+// to their final location and standalone files are written to temporary
+// siblings; existing targets are only touched once everything is staged, and
+// then only by renames that can be undone.
+type transaction struct {
+	fs      TargetFS
+	roots   []string          // skill directories in plan order
+	stages  map[string]string // skill directory → staging directory
+	temps   map[string]string // standalone file or link → temporary sibling with new content
+	created []string          // directories created outside staging areas
+	undo    []func() error    // reverses committed renames
+	trash   []string          // displaced originals, removed once committed
+	log     *slog.Logger
+}
+
+// applyOps applies ops in a transaction, reporting each to progress as it is
+// staged and logging each once committed. If ctx is cancelled first, the
+// transaction is rolled back.
+func applyOps(ctx context.Context, fsys TargetFS, ops []Op, progress func(Event), log *slog.Logger) error {
+	if log == nil {
+		log = discard
+	}
+	tx := &transaction{fs: fsys, stages: map[string]string{}, temps: map[string]string{}, log: log}
+	err := tx.stage(ctx, ops, progress)
+	if err == nil {
+		err = tx.commit(ctx, ops)
+	}
+	if err != nil {
+		err = errors.Join(err, tx.rollback())
+		log.Error("rolled back", "err", err)
+		return err
+	}
+	for _, op := range ops {
+		logOp(log, op)
+	}
+	tx.cleanup()
+	return nil
+}
+
+// logOp records a committed operation.
+func logOp(log *slog.Logger, op Op) {
+	attrs := []any{"path", op.Path, "skill", op.Skill}
+	switch {
+	case op.Kind == OpMkdir:
+		log.Debug(op.Kind.String(), attrs...)
+		return
+	case op.Kind == OpSymlink:
+		attrs = append(attrs, "link", op.Link)
+	case op.agent != "":
+		attrs = append(attrs, "agent", op.agent, op.extra.String(), true)
+	case filepath.Base(op.Path) == manifestName:
+		attrs = append(attrs, "manifest", true)
+	}
+	if op.Kind != OpDelete {
+		attrs = append(attrs, "bytes", len(op.Data))
+	}
+	log.Info(op.Kind.String(), attrs...)
+}
+
+// stage prepares every operation without modifying any existing target.
+func (tx *transaction) stage(ctx context.Context, ops []Op, progress func(Event)) error {
+	for i, op := range ops {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := tx.stageOp(op); err != nil {
+			return err
+		}
+		if e, ok := op.event(); ok {
+			e.Done, e.Total = i+1, len(ops)
+			emit(progress, e)
+		}
+	}
+	return nil
+}
+
+// stageOp prepares a single operation; see stage.
+func (tx *transaction) stageOp(op Op) error {
+	if op.root != "" {
+		stage, err := tx.stageDir(op.root, op.Kind == OpDelete && op.Path == op.root)
+		if err != nil {
+			return fmt.Errorf("instill: staging %s: %w", op.root, err)
+		}
+		rebased := Op{Kind: op.Kind, Path: stage + op.Path[len(op.root):], Data: op.Data}
+		if err := applyOp(tx.fs, rebased); err != nil {
+			return fmt.Errorf("instill: %s %s: %w", op.Kind, op.Path, err)
+		}
+		return nil
+	}
+	switch op.Kind {
+	case OpMkdir:
+		if err := tx.mkdirAll(op.Path); err != nil {
+			return fmt.Errorf("instill: creating %s: %w", op.Path, err)
+		}
+	case OpWrite, OpOverwrite:
+		if err := tx.stageFile(op.Path, op.Data); err != nil {
+			return fmt.Errorf("instill: writing %s: %w", op.Path, err)
+		}
+	case OpSymlink:
+		if err := tx.stageLink(op); err != nil {
+			return fmt.Errorf("instill: linking %s: %w", op.Path, err)
+		}
+	}
+	return nil
+}
+
+// stageDir returns the staging directory for root, creating it and seeding it
+// with root's current contents on first use.
+func (tx *transaction) stageDir(root string, empty bool) (string, error) {
+	if stage, ok := tx.stages[root]; ok {
+		return stage, nil
+	}
+	if err := tx.mkdirAll(filepath.Dir(root)); err != nil {
+		return "", err
+	}
+	stage, err := tempName(tx.fs, root, ".instill-")
+	if err != nil {
+		return "", err
+	}
+	if err := tx.fs.MkdirAll(stage, 0o755); err != nil {
+		return "", err
+	}
+	tx.roots = append(tx.roots, root)
+	tx.stages[root] = stage
+	if err := tx.fs.Chmod(stage, 0o755); err != nil {
+		return "", err
+	}
+	if fi, err := tx.fs.Lstat(root); err == nil && fi.IsDir() && !empty {
+		if err := copyTree(tx.fs, root, stage); err != nil {
+			return "", err
+		}
+	}
+	return stage, nil
+}
+
+func (tx *transaction) stageFile(target string, data []byte) error {
+	if err := tx.mkdirAll(filepath.Dir(target)); err != nil {
+		return err
+	}
+	temp, err := tempName(tx.fs, target, ".instill-")
+	if err != nil {
+		return err
+	}
+	tx.temps[target] = temp
+	if err := tx.fs.WriteFile(temp, data, 0o644); err != nil {
+		return err
+	}
+	return tx.fs.Chmod(temp, 0o644)
+}
+
+// stageLink creates a symlink to op.Link next to op.Path. Where links cannot
+// be created, it copies the directory the link would point to instead, as
+// staged so far, with op.Data as the copy's manifest.
+func (tx *transaction) stageLink(op Op) error {
+	dir := filepath.Dir(op.Path)
+	if err := tx.mkdirAll(dir); err != nil {
+		return err
+	}
+	temp, err := tempName(tx.fs, op.Path, ".instill-")
+	if err != nil {
+		return err
+	}
+	tx.temps[op.Path] = temp
+	if err := tx.fs.Symlink(op.Link, temp); err == nil {
+		return nil
+	}
+	src := op.Link
+	if !filepath.IsAbs(src) {
+		src = filepath.Join(dir, src)
+	}
+	if stage, ok := tx.stages[src]; ok {
+		src = stage
+	}
+	if err := copyTree(tx.fs, src, temp); err != nil {
+		return err
+	}
+	return tx.fs.WriteFile(filepath.Join(temp, manifestName), op.Data, 0o644)
+}
+
+// mkdirAll creates dir and remembers the topmost directory it had to create
+// so that rollback can remove it again.
+func (tx *transaction) mkdirAll(dir string) error {
+	missing := ""
+	for d := dir; ; d = filepath.Dir(d) {
+		if _, err := tx.fs.Stat(d); err == nil {
+			break
+		}
+		missing = d
+		if filepath.Dir(d) == d {
+			break
+		}
+	}
+	if missing == "" {
+		return nil
+	}
+	if err := tx.fs.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	tx.created = append(tx.created, missing)
+	return nil
+}
+
+// commit moves staged content into place.
+func (tx *transaction) commit(ctx context.Context, ops []Op) error {
+	for _, root := range tx.roots {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := tx.displace(root); err != nil {
+			return fmt.Errorf("instill: replacing %s: %w", root, err)
+		}
+		stage := tx.stages[root]
+		if _, err := tx.fs.Lstat(stage); err != nil {
+			continue // the plan deletes root
+		}
+		if err := tx.fs.Rename(stage, root); err != nil {
+			return fmt.Errorf("instill: replacing %s: %w", root, err)
+		}
+		tx.undo = append(tx.undo, func() error { return tx.fs.Rename(root, stage) })
+	}
+	for _, op := range ops {
+		if op.root != "" {
+			continue
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		switch op.Kind {
+		case OpWrite, OpOverwrite, OpSymlink:
+			if err := tx.displace(op.Path); err != nil {
+				return fmt.Errorf("instill: writing %s: %w", op.Path, err)
+			}
+			temp := tx.temps[op.Path]
+			if err := tx.fs.Rename(temp, op.Path); err != nil {
+				return fmt.Errorf("instill: writing %s: %w", op.Path, err)
+			}
+			tx.undo = append(tx.undo, func() error { return tx.fs.Rename(op.Path, temp) })
+		case OpDelete:
+			if err := tx.displace(op.Path); err != nil {
+				return fmt.Errorf("instill: deleting %s: %w", op.Path, err)
+			}
+		}
+	}
+	return nil
+}
+
+// displace moves an existing p out of the way so it can be restored on rollback.
+func (tx *transaction) displace(p string) error {
+	if _, err := tx.fs.Lstat(p); errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	backup, err := tempName(tx.fs, p, ".instill-old-")
+	if err != nil {
+		return err
+	}
+	if err := tx.fs.Rename(p, backup); err != nil {
+		return err
+	}
+	tx.trash = append(tx.trash, backup)
+	tx.undo = append(tx.undo, func() error { return tx.fs.Rename(backup, p) })
+	return nil
+}
+
+// rollback reverses committed renames and discards everything staged.
+func (tx *transaction) rollback() error {
+	var errs []error
+	for i := len(tx.undo) - 1; i >= 0; i-- {
+		if err := tx.undo[i](); err != nil {
+			errs = append(errs, fmt.Errorf("instill: rollback: %w", err))
+		}
+	}
+	for _, stage := range tx.stages {
+		tx.removeAll(stage)
+	}
+	for _, temp := range tx.temps {
+		tx.removeAll(temp)
+	}
+	for i := len(tx.created) - 1; i >= 0; i-- {
+		tx.removeAll(tx.created[i])
+	}
+	return errors.Join(errs...)
+}
+
+func (tx *transaction) cleanup() {
+	for _, p := range tx.trash {
+		tx.removeAll(p)
+	}
+}
+
+// removeAll removes leftovers of the transaction. Failing to do so leaves
+// hidden files behind but does not fail the operation, so it is only logged.
+func (tx *transaction) removeAll(p string) {
+	if err := tx.fs.RemoveAll(p); err != nil {
+		tx.log.Warn("cannot remove leftover", "path", p, "err", err)
+	}
+}
+
+// copyTree copies the regular files, directories and symlinks under src into dst.
+func copyTree(fsys TargetFS, src, dst string) error {
+	return walkDir(fsys, src, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, p)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+		switch {
+		case d.IsDir():
+			return fsys.MkdirAll(target, 0o755)
+		case d.Type()&fs.ModeSymlink != 0:
+			link, err := fsys.Readlink(p)
+			if err != nil {
+				return err
+			}
+			return fsys.Symlink(link, target)
+		default:
+			data, err := fsys.ReadFile(p)
+			if err != nil {
+				return err
+			}
+			info, err := d.Info()
+			if err != nil {
+				return err
+			}
+			return fsys.WriteFile(target, data, info.Mode().Perm())
+		}
+	})
+}
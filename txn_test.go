@@ -0,0 +1,87 @@
+package instill
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestInstallRollsBackAllTargets(t *testing.T) {
+	tmp := t.TempDir()
+	opts := Options{Agents: []string{"claude-code", "cursor"}, ProjectDir: tmp}
+	if _, err := Install(skillFSVersioned("x", "1.0"), opts); err != nil {
+		t.Fatal(err)
+	}
+
+	// Make the command directory unusable so the last staged write fails.
+	if err := os.WriteFile(filepath.Join(tmp, ".claude/commands"), []byte("not a dir"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	fsys := skillFSVersioned("x", "2.0")
+	fsys["_commands/run.md"] = &fstest.MapFile{Data: []byte("# run")}
+	if _, err := Install(fsys, opts); err == nil {
+		t.Fatal("expected error")
+	}
+
+	for _, dir := range []string{".agents/skills", ".claude/skills"} {
+		if v := installedVersionAt(OSFS{}, filepath.Join(tmp, dir, "x")); v != "1.0" {
+			t.Errorf("%s: version = %q after failed install, want 1.0", dir, v)
+		}
+		entries, err := os.ReadDir(filepath.Join(tmp, dir))
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, e := range entries {
+			if strings.Contains(e.Name(), ".instill-") {
+				t.Errorf("%s: leftover staging entry %s", dir, e.Name())
+			}
+		}
+	}
+}
+
+func TestInstallRollbackRemovesCreatedDirs(t *testing.T) {
+	tmp := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(tmp, ".claude"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(tmp, ".claude/agents"), []byte("not a dir"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	fsys := skillFS("x")
+	fsys["_agents/helper.md"] = &fstest.MapFile{Data: []byte("# helper")}
+
+	if _, err := Install(fsys, Options{Agents: []string{"claude-code"}, ProjectDir: tmp}); err == nil {
+		t.Fatal("expected error")
+	}
+	if _, err := os.Stat(filepath.Join(tmp, ".claude/skills")); !os.IsNotExist(err) {
+		t.Error("skills dir created by the failed install should be removed")
+	}
+}
+
+func TestInstallReplacesAtomically(t *testing.T) {
+	tmp := t.TempDir()
+	dir := filepath.Join(tmp, ".claude/skills/x")
+	opts := Options{Agents: []string{"claude-code"}, ProjectDir: tmp}
+	if _, err := Install(skillFSWithRef("x"), opts); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Install(skillFSVersioned("x", "2.0"), opts); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "references")); !os.IsNotExist(err) {
+		t.Error("stale references should be gone")
+	}
+	fi, err := os.Stat(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fi.Mode().Perm() != 0o755 {
+		t.Errorf("skill dir mode = %v, want 0755", fi.Mode().Perm())
+	}
+	entries, _ := os.ReadDir(filepath.Dir(dir))
+	if len(entries) != 1 {
+		t.Errorf("expected only the skill dir, got %v", entries)
+	}
+}
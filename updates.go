@@ -0,0 +1,147 @@
+package instill
+
+import (
+	"bytes"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"maps"
+	"path/filepath"
+	"slices"
+)
+
+// UpdateStatus compares an installed copy of a skill with the bundled one.
+type UpdateStatus int
+
+const (
+	UpdateMissing UpdateStatus = iota // the skill is not installed
+	UpdateOlder                       // the installed version is older
+	UpdateEqual                       // same version, or same content if unversioned
+	UpdateNewer                       // the installed version is newer
+	UpdateDiffers                     // versions are missing or not comparable and the content differs
+)
+
+var updateStatusNames = [...]string{"missing", "older", "equal", "newer", "differs"}
+
+func (s UpdateStatus) String() string {
+	if int(s) < len(updateStatusNames) {
+		return updateStatusNames[s]
+	}
+	return fmt.Sprintf("UpdateStatus(%d)", int(s))
+}
+
+func (s UpdateStatus) MarshalText() ([]byte, error) { return []byte(s.String()), nil }
+
+// SkillUpdate reports how one agent's installed copy of a skill compares with
+// the bundled skill.
+type SkillUpdate struct {
+	Skill     string
+	Agent     string
+	Scope     Scope
+	Path      string // skill directory for the agent
+	Bundled   string // bundled version ("" if unversioned)
+	Installed string // installed version ("" if unversioned or missing)
+	Status    UpdateStatus
+}
+
+// Outdated reports whether installing the bundled skill would update the
+// installed copy: it is older, or differs without comparable versions.
+func (u SkillUpdate) Outdated() bool {
+	return u.Status == UpdateOlder || u.Status == UpdateDiffers
+}
+
+// CheckUpdates compares every skill in fsys with its installed copies for each
+// of opts.Agents in the scope selected by opts.Global. Versions are compared
+// as semantic versions; when either side is unversioned or not a valid
+// version, the installed files are compared with the bundled ones instead.
+// Results are ordered by skill, then by agent as given in opts.Agents.
+func CheckUpdates(fsys fs.FS, opts Options) ([]SkillUpdate, error) {
+	if len(opts.Agents) == 0 {
+		return nil, fmt.Errorf("instill: no agents specified")
+	}
+	skills, err := findSkills(fsys)
+	if err != nil {
+		return nil, err
+	}
+	set, err := agentsFor(opts.ProjectDir)
+	if err != nil {
+		return nil, err
+	}
+	targets, err := resolveTargets(set, opts)
+	if err != nil {
+		return nil, err
+	}
+	dirOf := map[string]string{}
+	for dir, names := range targets {
+		for _, n := range names {
+			dirOf[n] = dir
+		}
+	}
+	scope := ScopeProject
+	if opts.Global {
+		scope = ScopeGlobal
+	}
+
+	var out []SkillUpdate
+	for _, s := range skills {
+		if len(opts.Skills) > 0 && !slices.Contains(opts.Skills, s.name) {
+			continue
+		}
+		bundled := ""
+		if meta, err := parseFrontmatter(s.files["SKILL.md"]); err == nil {
+			bundled = meta.Version
+		}
+		// Agents sharing a directory share the comparison.
+		statuses := map[string]SkillUpdate{}
+		for _, an := range opts.Agents {
+			dir := dirOf[an]
+			u, ok := statuses[dir]
+			if !ok {
+				u = compareInstalled(opts.targetFS(), filepath.Join(dir, s.name), s, bundled, opts.logger())
+				statuses[dir] = u
+			}
+			u.Agent, u.Scope = an, scope
+			out = append(out, u)
+		}
+	}
+	return out, nil
+}
+
+func compareInstalled(fsys TargetFS, skillDir string, s skillEntry, bundled string, log *slog.Logger) SkillUpdate {
+	u := SkillUpdate{Skill: s.name, Path: skillDir, Bundled: bundled}
+	if _, err := fsys.Stat(filepath.Join(skillDir, "SKILL.md")); err != nil {
+		u.Status = UpdateMissing
+		return u
+	}
+	u.Installed = installedVersionAt(fsys, skillDir)
+	if c, ok := compareVersions(u.Installed, bundled); ok {
+		u.Status = [...]UpdateStatus{UpdateOlder, UpdateEqual, UpdateNewer}[c+1]
+		return u
+	}
+	u.Status = UpdateEqual
+	if contentDiffers(fsys, skillDir, s.files, readManifest(fsys, skillDir, log).Files) {
+		u.Status = UpdateDiffers
+	}
+	return u
+}
+
+// contentDiffers reports whether the files in skillDir differ from files.
+// Files instill installed earlier that are no longer bundled count as
+// differences; other files added locally do not.
+func contentDiffers(fsys TargetFS, skillDir string, files map[string][]byte, recorded map[string]string) bool {
+	for _, rel := range slices.Sorted(maps.Keys(files)) {
+		data, err := fsys.ReadFile(filepath.Join(skillDir, filepath.FromSlash(rel)))
+		if err != nil || !bytes.Equal(data, files[rel]) {
+			return true
+		}
+	}
+	for rel := range recorded {
+		if _, ok := files[rel]; ok {
+			continue
+		}
+		if _, err := fsys.Lstat(filepath.Join(skillDir, filepath.FromSlash(rel))); err == nil {
+			return true
+		}
+	}
+	return false
+}
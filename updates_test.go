@@ -0,0 +1,95 @@
+package instill
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+)
+
+func TestCheckUpdates(t *testing.T) {
+	project := t.TempDir()
+	install := func(fsys fstest.MapFS, agent string) {
+		t.Helper()
+		if _, err := Install(fsys, Options{Agents: []string{agent}, ProjectDir: project}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	install(skillFSVersioned("my-skill", "1.9.0"), "claude-code")
+	install(skillFSVersioned("my-skill", "1.10.0"), "cursor")
+	install(skillFSVersioned("my-skill", "2.0.0-beta.1"), "windsurf")
+
+	bundled := skillFSVersioned("my-skill", "1.10.0")
+	agents := []string{"claude-code", "cursor", "codex", "windsurf", "roo"}
+	got, err := CheckUpdates(bundled, Options{Agents: agents, ProjectDir: project})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []UpdateStatus{UpdateOlder, UpdateEqual, UpdateEqual, UpdateNewer, UpdateMissing}
+	if len(got) != len(want) {
+		t.Fatalf("got %d results, want %d: %+v", len(got), len(want), got)
+	}
+	for i, u := range got {
+		if u.Agent != agents[i] || u.Status != want[i] || u.Bundled != "1.10.0" || u.Scope != ScopeProject {
+			t.Errorf("result %d = %+v, want %s for %s", i, u, want[i], agents[i])
+		}
+	}
+	if !got[0].Outdated() || got[0].Installed != "1.9.0" || got[3].Outdated() || got[4].Outdated() {
+		t.Errorf("Outdated wrong: %+v", got)
+	}
+}
+
+func TestCheckUpdatesUnversioned(t *testing.T) {
+	project := t.TempDir()
+	opts := Options{Agents: []string{"claude-code"}, ProjectDir: project}
+	bundled := skillFSWithRef("my-skill")
+	if _, err := Install(bundled, opts); err != nil {
+		t.Fatal(err)
+	}
+	check := func(want UpdateStatus) {
+		t.Helper()
+		got, err := CheckUpdates(bundled, opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(got) != 1 || got[0].Status != want {
+			t.Errorf("got %+v, want %s", got, want)
+		}
+	}
+	check(UpdateEqual)
+
+	// Files added locally are not differences.
+	skillDir := filepath.Join(project, ".claude/skills/my-skill")
+	os.WriteFile(filepath.Join(skillDir, "notes.md"), []byte("mine"), 0o644)
+	check(UpdateEqual)
+
+	// A bundled file that changed is.
+	bundled["skills/my-skill/references/commands.md"] = &fstest.MapFile{Data: []byte("# Commands v2\n")}
+	check(UpdateDiffers)
+	if _, err := Install(bundled, opts); err != nil {
+		t.Fatal(err)
+	}
+	check(UpdateEqual)
+
+	// So is an installed file that is no longer bundled.
+	delete(bundled, "skills/my-skill/references/commands.md")
+	check(UpdateDiffers)
+}
+
+func TestCheckUpdatesInvalidVersion(t *testing.T) {
+	project := t.TempDir()
+	opts := Options{Agents: []string{"claude-code"}, ProjectDir: project}
+	if _, err := Install(skillFSVersioned("my-skill", "latest"), opts); err != nil {
+		t.Fatal(err)
+	}
+	got, err := CheckUpdates(skillFSVersioned("my-skill", "1.0.0"), opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got[0].Status != UpdateDiffers || got[0].Installed != "latest" {
+		t.Errorf("got %+v", got[0])
+	}
+	if _, err := CheckUpdates(skillFS("x"), Options{ProjectDir: project}); err == nil {
+		t.Error("expected error without agents")
+	}
+}
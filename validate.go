@@ -0,0 +1,306 @@
+package instill
+
+import (
+	"bufio"
+	"bytes"
+	"cmp"
+	"errors"
+	"fmt"
+	"io/fs"
+	"maps"
+	"net/url"
+	"path"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Severity of a Diagnostic.
+type Severity int
+
+const (
+	SeverityError   Severity = iota // agents may refuse to load the skill
+	SeverityWarning                 // the skill loads, but something is likely wrong
+)
+
+func (s Severity) String() string {
+	if s == SeverityWarning {
+		return "warning"
+	}
+	return "error"
+}
+
+func (s Severity) MarshalText() ([]byte, error) { return []byte(s.String()), nil }
+
+// Diagnostic is a problem found by Validate.
+type Diagnostic struct {
+	File     string // path within the validated filesystem
+	Line     int    // 1-based line number, or 0 if not tied to a line
+	Severity Severity
+	Rule     string // stable rule ID, e.g. "name-format"
+	Message  string
+}
+
+func (d Diagnostic) String() string {
+	loc := d.File
+	if d.Line > 0 {
+		loc += ":" + strconv.Itoa(d.Line)
+	}
+	return fmt.Sprintf("%s: %s: %s [%s]", loc, d.Severity, d.Message, d.Rule)
+}
+
+// Validation limits from the Agent Skills specification.
+const (
+	maxNameLen          = 64
+	maxDescriptionLen   = 1024
+	maxCompatibilityLen = 500
+)
+
+// specFields are the frontmatter fields defined by the specification, plus
+// version, which instill reads for upgrades.
+var specFields = map[string]bool{
+	"name": true, "description": true, "license": true, "compatibility": true,
+	"metadata": true, "allowed-tools": true, "version": true,
+}
+
+var (
+	validName    = regexp.MustCompile(`^[a-z0-9]+(-[a-z0-9]+)*$`)
+	markdownLink = regexp.MustCompile(`!?\[[^\]]*\]\(\s*<?([^)\s>]+)>?(?:\s+"[^"]*")?\s*\)`)
+)
+
+// Validate checks every skill in fsys against the Agent Skills specification
+// and returns the problems found, ordered by file and line. The error is
+// non-nil only if fsys itself cannot be read.
+func Validate(fsys fs.FS) ([]Diagnostic, error) {
+	var out []Diagnostic
+	err := fs.WalkDir(fsys, ".", func(p string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || d.Name() != "SKILL.md" {
+			return err
+		}
+		diags, err := validateSkill(fsys, path.Dir(p))
+		if err != nil {
+			return err
+		}
+		out = append(out, diags...)
+		return fs.SkipDir
+	})
+	slices.SortStableFunc(out, func(a, b Diagnostic) int {
+		return cmp.Or(strings.Compare(a.File, b.File), cmp.Compare(a.Line, b.Line))
+	})
+	return out, err
+}
+
+func validateSkill(fsys fs.FS, dir string) ([]Diagnostic, error) {
+	skillFile := path.Join(dir, "SKILL.md")
+	data, err := fs.ReadFile(fsys, skillFile)
+	if err != nil {
+		return nil, err
+	}
+	v := &validator{file: skillFile}
+	v.frontmatter(data, dir)
+
+	files := map[string]bool{}
+	err = fs.WalkDir(fsys, dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if p != dir && skipDirs[d.Name()] {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		v.file = p
+		v.fileName(d.Name())
+		if !isExcluded(d.Name()) {
+			files[p] = true
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range slices.Sorted(maps.Keys(files)) {
+		if path.Ext(p) != ".md" {
+			continue
+		}
+		content, err := fs.ReadFile(fsys, p)
+		if err != nil {
+			return nil, err
+		}
+		v.file = p
+		v.links(fsys, content, dir, files)
+	}
+	return v.diags, nil
+}
+
+type validator struct {
+	file  string
+	diags []Diagnostic
+}
+
+func (v *validator) report(line int, sev Severity, rule, format string, args ...any) {
+	v.diags = append(v.diags, Diagnostic{File: v.file, Line: line, Severity: sev, Rule: rule, Message: fmt.Sprintf(format, args...)})
+}
+
+func (v *validator) frontmatter(data []byte, dir string) {
+	root, err := decodeFrontmatter(data)
+	if err != nil {
+		line := 1
+		if ye := (*yamlError)(nil); errors.As(err, &ye) {
+			line = ye.line
+		}
+		v.report(line, SeverityError, "frontmatter", "%v", err)
+		return
+	}
+
+	fields := map[string]*yamlNode{}
+	for i := 0; i+1 < len(root.content); i += 2 {
+		key := root.content[i]
+		fields[key.value] = root.content[i+1]
+		if !specFields[key.value] {
+			v.report(key.line, SeverityWarning, "unknown-field", "field %q is not defined by the Agent Skills specification", key.value)
+		}
+	}
+
+	name, ok := v.stringField(fields, "name")
+	switch {
+	case !ok:
+	case name == "":
+		v.report(root.line, SeverityError, "name-required", "frontmatter is missing the required name field")
+	default:
+		line := fields["name"].line
+		if n := utf8.RuneCountInString(name); n > maxNameLen {
+			v.report(line, SeverityError, "name-length", "name is %d characters long; the limit is %d", n, maxNameLen)
+		}
+		if !validName.MatchString(name) {
+			v.report(line, SeverityError, "name-format", "name %q must contain only lowercase letters, digits and single hyphens, and must not start or end with a hyphen", name)
+		}
+		if dir != "." && path.Base(dir) != name {
+			v.report(line, SeverityError, "name-directory", "name %q does not match the skill directory %q", name, path.Base(dir))
+		}
+	}
+
+	desc, ok := v.stringField(fields, "description")
+	switch {
+	case !ok:
+	case desc == "":
+		v.report(root.line, SeverityError, "description-required", "frontmatter is missing the required description field")
+	case utf8.RuneCountInString(desc) > maxDescriptionLen:
+		v.report(fields["description"].line, SeverityError, "description-length", "description is %d characters long; the limit is %d", utf8.RuneCountInString(desc), maxDescriptionLen)
+	}
+
+	if c, ok := v.stringField(fields, "compatibility"); ok && utf8.RuneCountInString(c) > maxCompatibilityLen {
+		v.report(fields["compatibility"].line, SeverityError, "compatibility-length", "compatibility is %d characters long; the limit is %d", utf8.RuneCountInString(c), maxCompatibilityLen)
+	}
+	v.stringField(fields, "license")
+	v.stringField(fields, "version")
+
+	if n := fields["metadata"]; n != nil && !n.isNull() {
+		if n.kind != yamlMapping {
+			v.report(n.line, SeverityError, "field-type", "metadata must be a mapping of strings")
+		} else {
+			for i := 0; i+1 < len(n.content); i += 2 {
+				if n.content[i+1].kind != yamlScalar {
+					v.report(n.content[i+1].line, SeverityError, "field-type", "metadata.%s must be a string", n.content[i].value)
+				}
+			}
+		}
+	}
+	if n := fields["allowed-tools"]; n != nil {
+		switch n.kind {
+		case yamlScalar:
+		case yamlSequence:
+			for _, item := range n.content {
+				if item.kind != yamlScalar {
+					v.report(item.line, SeverityError, "field-type", "allowed-tools items must be strings")
+				}
+			}
+		default:
+			v.report(n.line, SeverityError, "field-type", "allowed-tools must be a space-delimited string")
+		}
+	}
+}
+
+// stringField returns the value of a scalar field. ok is false if the field
+// is present with a non-scalar value, which is reported.
+func (v *validator) stringField(fields map[string]*yamlNode, key string) (string, bool) {
+	n := fields[key]
+	if n == nil {
+		return "", true
+	}
+	s, ok := scalar(n)
+	if !ok {
+		v.report(n.line, SeverityError, "field-type", "%s must be a string", key)
+		return "", false
+	}
+	return s, true
+}
+
+// windowsReserved are device names that cannot be used as file names on Windows.
+var windowsReserved = map[string]bool{
+	"CON": true, "PRN": true, "AUX": true, "NUL": true,
+	"COM1": true, "COM2": true, "COM3": true, "COM4": true, "COM5": true, "COM6": true, "COM7": true, "COM8": true, "COM9": true,
+	"LPT1": true, "LPT2": true, "LPT3": true, "LPT4": true, "LPT5": true, "LPT6": true, "LPT7": true, "LPT8": true, "LPT9": true,
+}
+
+func (v *validator) fileName(name string) {
+	switch {
+	case name == manifestName:
+		v.report(0, SeverityError, "forbidden-file", "%s is reserved for instill's install manifest", name)
+	case strings.ContainsAny(name, `<>:"\|?*`) || strings.HasSuffix(name, ".") || strings.HasSuffix(name, " "):
+		v.report(0, SeverityError, "forbidden-file", "file name %q is not valid on Windows", name)
+	case windowsReserved[strings.ToUpper(strings.TrimSuffix(name, path.Ext(name)))]:
+		v.report(0, SeverityError, "forbidden-file", "file name %q is reserved on Windows", name)
+	}
+}
+
+// links reports relative links in a markdown file that do not resolve to a
+// file installed with the skill.
+func (v *validator) links(fsys fs.FS, content []byte, dir string, files map[string]bool) {
+	sc := bufio.NewScanner(bytes.NewReader(content))
+	inFence := false
+	for line := 1; sc.Scan(); line++ {
+		text := sc.Text()
+		if t := strings.TrimSpace(text); strings.HasPrefix(t, "```") || strings.HasPrefix(t, "~~~") {
+			inFence = !inFence
+			continue
+		}
+		if inFence {
+			continue
+		}
+		for _, m := range markdownLink.FindAllStringSubmatch(text, -1) {
+			target := m[1]
+			u, err := url.Parse(target)
+			if err != nil || u.Scheme != "" || u.Host != "" || u.Path == "" || strings.HasPrefix(u.Path, "/") {
+				continue
+			}
+			p := path.Join(path.Dir(v.file), u.Path)
+			switch {
+			case dir != "." && !strings.HasPrefix(p, dir+"/"), p == ".." || strings.HasPrefix(p, "../"):
+				v.report(line, SeverityError, "broken-link", "link %q points outside the skill directory", target)
+			case files[p] || isDir(p, files):
+			case exists(fsys, p):
+				v.report(line, SeverityError, "broken-link", "link %q points to a file that is not installed", target)
+			default:
+				v.report(line, SeverityError, "broken-link", "link %q points to a missing file", target)
+			}
+		}
+	}
+}
+
+func exists(fsys fs.FS, p string) bool {
+	_, err := fs.Stat(fsys, p)
+	return err == nil
+}
+
+func isDir(p string, files map[string]bool) bool {
+	for f := range files {
+		if strings.HasPrefix(f, p+"/") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,81 @@
+package instill
+
+import (
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func rules(diags []Diagnostic) map[string]Diagnostic {
+	m := map[string]Diagnostic{}
+	for _, d := range diags {
+		m[d.Rule] = d
+	}
+	return m
+}
+
+func TestValidateClean(t *testing.T) {
+	fsys := fstest.MapFS{
+		"skills/pdf/SKILL.md":               &fstest.MapFile{Data: []byte("---\nname: pdf\ndescription: Work with PDFs.\nversion: 1.0\nmetadata:\n  author: me\n---\n\nSee [commands](references/commands.md) and [docs](https://example.com).\n\n```\n[not a link](missing.md)\n```\n")},
+		"skills/pdf/references/commands.md": &fstest.MapFile{Data: []byte("Back to [skill](../SKILL.md#usage).\n")},
+	}
+	diags, err := Validate(fsys)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(diags) != 0 {
+		t.Errorf("expected no diagnostics, got %v", diags)
+	}
+}
+
+func TestValidateRules(t *testing.T) {
+	long := strings.Repeat("a", maxDescriptionLen+1)
+	for _, tt := range []struct {
+		name string
+		fsys fstest.MapFS
+		rule string
+		file string
+		line int
+		sev  Severity
+	}{
+		{"missing delimiters", fstest.MapFS{"x/SKILL.md": {Data: []byte("# x\n")}}, "frontmatter", "x/SKILL.md", 1, SeverityError},
+		{"yaml syntax", fstest.MapFS{"x/SKILL.md": {Data: []byte("---\nname: x\ndescription: a: b\n---\n")}}, "frontmatter", "x/SKILL.md", 3, SeverityError},
+		{"name required", fstest.MapFS{"x/SKILL.md": {Data: []byte("---\ndescription: d\n---\n")}}, "name-required", "x/SKILL.md", 2, SeverityError},
+		{"name format", fstest.MapFS{"My_Skill/SKILL.md": {Data: []byte("---\nname: My_Skill\ndescription: d\n---\n")}}, "name-format", "My_Skill/SKILL.md", 2, SeverityError},
+		{"double hyphen", fstest.MapFS{"a--b/SKILL.md": {Data: []byte("---\nname: a--b\ndescription: d\n---\n")}}, "name-format", "a--b/SKILL.md", 2, SeverityError},
+		{"name length", fstest.MapFS{"SKILL.md": {Data: []byte("---\nname: " + strings.Repeat("a", 65) + "\ndescription: d\n---\n")}}, "name-length", "SKILL.md", 2, SeverityError},
+		{"name directory", fstest.MapFS{"y/SKILL.md": {Data: []byte("---\nname: x\ndescription: d\n---\n")}}, "name-directory", "y/SKILL.md", 2, SeverityError},
+		{"description required", fstest.MapFS{"x/SKILL.md": {Data: []byte("---\nname: x\n---\n")}}, "description-required", "x/SKILL.md", 2, SeverityError},
+		{"description length", fstest.MapFS{"x/SKILL.md": {Data: []byte("---\nname: x\ndescription: " + long + "\n---\n")}}, "description-length", "x/SKILL.md", 3, SeverityError},
+		{"field type", fstest.MapFS{"x/SKILL.md": {Data: []byte("---\nname: x\ndescription: d\nmetadata:\n  tags: [a]\n---\n")}}, "field-type", "x/SKILL.md", 5, SeverityError},
+		{"allowed-tools item type", fstest.MapFS{"x/SKILL.md": {Data: []byte("---\nname: x\ndescription: d\nallowed-tools:\n  - Read\n  - [Write]\n---\n")}}, "field-type", "x/SKILL.md", 6, SeverityError},
+		{"unknown field", fstest.MapFS{"x/SKILL.md": {Data: []byte("---\nname: x\ndescription: d\nauthor: me\n---\n")}}, "unknown-field", "x/SKILL.md", 4, SeverityWarning},
+		{"broken link", fstest.MapFS{"x/SKILL.md": {Data: []byte("---\nname: x\ndescription: d\n---\n\nRead [this](references/missing.md).\n")}}, "broken-link", "x/SKILL.md", 6, SeverityError},
+		{"link to excluded", fstest.MapFS{"x/SKILL.md": {Data: []byte("---\nname: x\ndescription: d\n---\nSee [readme](README.md)\n")}, "x/README.md": {}}, "broken-link", "x/SKILL.md", 5, SeverityError},
+		{"link outside", fstest.MapFS{"x/SKILL.md": {Data: []byte("---\nname: x\ndescription: d\n---\nSee [y](../y/SKILL.md)\n")}, "y/SKILL.md": {Data: []byte("---\nname: y\ndescription: d\n---\n")}}, "broken-link", "x/SKILL.md", 5, SeverityError},
+		{"broken link in reference", fstest.MapFS{"x/SKILL.md": {Data: []byte("---\nname: x\ndescription: d\n---\n")}, "x/references/a.md": {Data: []byte("\n[b](b.md)\n")}}, "broken-link", "x/references/a.md", 2, SeverityError},
+		{"reserved manifest", fstest.MapFS{"x/SKILL.md": {Data: []byte("---\nname: x\ndescription: d\n---\n")}, "x/.instill.json": {}}, "forbidden-file", "x/.instill.json", 0, SeverityError},
+		{"windows name", fstest.MapFS{"x/SKILL.md": {Data: []byte("---\nname: x\ndescription: d\n---\n")}, "x/what?.md": {}}, "forbidden-file", "x/what?.md", 0, SeverityError},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			diags, err := Validate(tt.fsys)
+			if err != nil {
+				t.Fatal(err)
+			}
+			d, ok := rules(diags)[tt.rule]
+			if !ok {
+				t.Fatalf("expected %s, got %v", tt.rule, diags)
+			}
+			if d.File != tt.file || d.Line != tt.line || d.Severity != tt.sev {
+				t.Errorf("got %s, want %s:%d %s", d, tt.file, tt.line, tt.sev)
+			}
+		})
+	}
+}
+
+func TestDiagnosticString(t *testing.T) {
+	d := Diagnostic{File: "x/SKILL.md", Line: 2, Severity: SeverityWarning, Rule: "unknown-field", Message: "oops"}
+	if got, want := d.String(), "x/SKILL.md:2: warning: oops [unknown-field]"; got != want {
+		t.Errorf("String() = %q, want %q", got, want)
+	}
+}
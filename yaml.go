@@ -0,0 +1,584 @@
+package instill
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// This file parses the subset of YAML that SKILL.md frontmatter uses: block
+// mappings and sequences, flow lists and mappings, plain and quoted scalars,
+// and literal and folded block scalars. Anchors, aliases, tags and multiple
+// documents are rejected.
+
+type yamlKind int
+
+const (
+	yamlScalar yamlKind = iota
+	yamlSequence
+	yamlMapping
+)
+
+// yamlNode is a parsed YAML value.
+type yamlNode struct {
+	kind    yamlKind
+	value   string      // text of a scalar
+	plain   bool        // the scalar was unquoted, so it may be a null, bool or number
+	line    int         // 1-based line the value starts on
+	content []*yamlNode // sequence items, or mapping keys and values in turn
+}
+
+// isNull reports whether n is an empty or null scalar.
+func (n *yamlNode) isNull() bool {
+	if n.kind != yamlScalar || !n.plain {
+		return false
+	}
+	switch n.value {
+	case "", "~", "null", "Null", "NULL":
+		return true
+	}
+	return false
+}
+
+// decode converts n to strings, bools, ints, float64s, []any and
+// map[string]any.
+func (n *yamlNode) decode() any {
+	switch n.kind {
+	case yamlSequence:
+		out := make([]any, len(n.content))
+		for i, c := range n.content {
+			out[i] = c.decode()
+		}
+		return out
+	case yamlMapping:
+		out := make(map[string]any, len(n.content)/2)
+		for i := 0; i+1 < len(n.content); i += 2 {
+			out[n.content[i].value] = n.content[i+1].decode()
+		}
+		return out
+	}
+	if !n.plain {
+		return n.value
+	}
+	if n.isNull() {
+		return nil
+	}
+	switch n.value {
+	case "true", "True", "TRUE":
+		return true
+	case "false", "False", "FALSE":
+		return false
+	}
+	if i, err := strconv.ParseInt(n.value, 0, 64); err == nil {
+		return int(i)
+	}
+	if c := n.value[0]; c >= '0' && c <= '9' || c == '-' || c == '+' || c == '.' {
+		if f, err := strconv.ParseFloat(n.value, 64); err == nil {
+			return f
+		}
+	}
+	return n.value
+}
+
+// yamlError is a syntax error at a line of the parsed text.
+type yamlError struct {
+	line int
+	msg  string
+}
+
+func (e *yamlError) Error() string { return fmt.Sprintf("line %d: %s", e.line, e.msg) }
+
+// parseYAML parses src, whose first line is line first of the file it comes
+// from. An empty document is a null scalar.
+func parseYAML(src string, first int) (*yamlNode, error) {
+	src = strings.TrimSuffix(strings.ReplaceAll(src, "\r\n", "\n"), "\n")
+	p := &yamlParser{lines: strings.Split(src, "\n"), first: first}
+	n, err := p.node(0)
+	if err != nil {
+		return nil, err
+	}
+	if p.next() {
+		return nil, p.errorf("unexpected content")
+	}
+	return n, nil
+}
+
+type yamlParser struct {
+	lines []string
+	pos   int // index of the current line
+	first int // file line number of lines[0]
+}
+
+func (p *yamlParser) line() int { return p.pos + p.first }
+
+func (p *yamlParser) errorf(format string, args ...any) error {
+	return &yamlError{line: p.line(), msg: fmt.Sprintf(format, args...)}
+}
+
+// next skips blank and comment lines and reports whether a line is left.
+func (p *yamlParser) next() bool {
+	for ; p.pos < len(p.lines); p.pos++ {
+		t := strings.TrimSpace(p.lines[p.pos])
+		if t != "" && t[0] != '#' && t != "---" && t != "..." {
+			return true
+		}
+	}
+	return false
+}
+
+// indent returns the indentation of the current line and its content.
+func (p *yamlParser) indent() (int, string, error) {
+	l := p.lines[p.pos]
+	n := len(l) - len(strings.TrimLeft(l, " "))
+	if n < len(l) && l[n] == '\t' {
+		return 0, "", p.errorf("tabs are not allowed for indentation")
+	}
+	return n, strings.TrimRight(l[n:], " \t"), nil
+}
+
+// node parses the value on the following lines if it is indented by at
+// least min, and returns a null scalar otherwise.
+func (p *yamlParser) node(min int) (*yamlNode, error) {
+	if !p.next() {
+		return &yamlNode{plain: true, line: p.line()}, nil
+	}
+	ind, text, err := p.indent()
+	if err != nil || ind < min {
+		return &yamlNode{plain: true, line: p.line()}, err
+	}
+	if isSeqItem(text) {
+		return p.sequence(ind)
+	}
+	if _, _, ok := splitKey(text); ok {
+		return p.mapping(ind)
+	}
+	p.pos++
+	return p.value(text, p.line()-1, min-1)
+}
+
+func isSeqItem(text string) bool {
+	return text == "-" || strings.HasPrefix(text, "- ")
+}
+
+func (p *yamlParser) sequence(ind int) (*yamlNode, error) {
+	seq := &yamlNode{kind: yamlSequence, line: p.line()}
+	for p.next() {
+		i, text, err := p.indent()
+		if err != nil {
+			return nil, err
+		}
+		if i < ind || i == ind && !isSeqItem(text) {
+			break
+		}
+		if i > ind {
+			return nil, p.errorf("bad indentation of a sequence item")
+		}
+		rest := strings.TrimLeft(text[1:], " ")
+		var item *yamlNode
+		if rest == "" || rest[0] == '#' {
+			p.pos++
+			item, err = p.node(ind + 1)
+		} else {
+			// Parse the item as if it started its own block, so that
+			// "- key: value" begins a mapping.
+			col := ind + len(text) - len(rest)
+			p.lines[p.pos] = strings.Repeat(" ", col) + rest
+			item, err = p.node(col)
+		}
+		if err != nil {
+			return nil, err
+		}
+		seq.content = append(seq.content, item)
+	}
+	return seq, nil
+}
+
+func (p *yamlParser) mapping(ind int) (*yamlNode, error) {
+	m := &yamlNode{kind: yamlMapping, line: p.line()}
+	seen := map[string]bool{}
+	for p.next() {
+		i, text, err := p.indent()
+		if err != nil {
+			return nil, err
+		}
+		if i < ind {
+			break
+		}
+		if i > ind {
+			return nil, p.errorf("bad indentation of a mapping entry")
+		}
+		key, rest, ok := splitKey(text)
+		if !ok {
+			if isSeqItem(text) {
+				break // a sequence at the level of its parent's key
+			}
+			return nil, p.errorf("could not find expected ':'")
+		}
+		k, err := p.keyNode(key)
+		if err != nil {
+			return nil, err
+		}
+		if seen[k.value] {
+			return nil, p.errorf("mapping key %q already defined", k.value)
+		}
+		seen[k.value] = true
+		line := p.line()
+		p.pos++
+		var val *yamlNode
+		switch {
+		case rest != "" && rest[0] != '#':
+			val, err = p.value(rest, line, ind)
+		case p.next() && p.sameLevelSeq(ind):
+			val, err = p.sequence(ind)
+		default:
+			val, err = p.node(ind + 1)
+			if err == nil && val.kind == yamlScalar && val.isNull() {
+				val.line = line
+			}
+		}
+		if err != nil {
+			return nil, err
+		}
+		m.content = append(m.content, k, val)
+	}
+	return m, nil
+}
+
+// sameLevelSeq reports whether the current line is a sequence item indented
+// by ind, which YAML allows as the value of a mapping key indented by ind.
+func (p *yamlParser) sameLevelSeq(ind int) bool {
+	i, text, err := p.indent()
+	return err == nil && i == ind && isSeqItem(text)
+}
+
+func (p *yamlParser) keyNode(key string) (*yamlNode, error) {
+	k := &yamlNode{value: key, plain: true, line: p.line()}
+	if key != "" && (key[0] == '"' || key[0] == '\'') {
+		s, rest, err := unquote(key)
+		if err != nil || rest != "" {
+			return nil, p.errorf("invalid quoted key %s", key)
+		}
+		k.value, k.plain = s, false
+	}
+	return k, nil
+}
+
+// splitKey splits a "key: value" line. ok is false if the line is not a
+// mapping entry.
+func splitKey(text string) (key, rest string, ok bool) {
+	if text == "" || isSeqItem(text) || strings.ContainsRune("#[]{},&*!|>%@`", rune(text[0])) {
+		return "", "", false
+	}
+	start := 0
+	if text[0] == '"' || text[0] == '\'' {
+		_, after, err := unquote(text)
+		if err != nil {
+			return "", "", false
+		}
+		start = len(text) - len(after)
+	}
+	for i := start; i < len(text); i++ {
+		if text[i] == '#' && i > 0 && text[i-1] == ' ' {
+			return "", "", false
+		}
+		if text[i] == ':' && (i+1 == len(text) || text[i+1] == ' ' || text[i+1] == '\t') {
+			return strings.TrimSpace(text[:i]), strings.TrimSpace(text[i+1:]), true
+		}
+	}
+	return "", "", false
+}
+
+// value parses a value that starts with text on the given line, followed by
+// continuation lines indented by more than parent.
+func (p *yamlParser) value(text string, line, parent int) (*yamlNode, error) {
+	switch text[0] {
+	case '|', '>':
+		return p.blockScalar(text, line, parent)
+	case '&', '*', '!', '%', '@', '`':
+		return nil, &yamlError{line, fmt.Sprintf("unsupported YAML syntax %q", text[0])}
+	}
+	text = p.continuation(text, parent)
+	switch text[0] {
+	case '"', '\'':
+		s, rest, err := unquote(text)
+		if err != nil {
+			return nil, &yamlError{line, err.Error()}
+		}
+		if rest != "" && rest[0] != '#' {
+			return nil, &yamlError{line, "unexpected text after quoted string"}
+		}
+		return &yamlNode{value: s, line: line}, nil
+	case '[', '{':
+		f := &flowParser{text: text, line: line}
+		n, err := f.node()
+		if err != nil {
+			return nil, err
+		}
+		if rest := strings.TrimSpace(f.text[f.pos:]); rest != "" && rest[0] != '#' {
+			return nil, &yamlError{line, "unexpected text after flow collection"}
+		}
+		return n, nil
+	}
+	if i := strings.Index(text, " #"); i >= 0 {
+		text = strings.TrimSpace(text[:i])
+	}
+	if strings.Contains(text, ": ") || strings.HasSuffix(text, ":") {
+		return nil, &yamlError{line, "mapping values are not allowed in this context"}
+	}
+	if isSeqItem(text) {
+		return nil, &yamlError{line, "block sequence entries are not allowed in this context"}
+	}
+	return &yamlNode{value: text, plain: true, line: line}, nil
+}
+
+// continuation appends the lines indented by more than parent to text,
+// folding line breaks into spaces and blank lines into line breaks.
+func (p *yamlParser) continuation(text string, parent int) string {
+	var b strings.Builder
+	b.WriteString(text)
+	blank := 0
+	for i := p.pos; i < len(p.lines); i++ {
+		l := strings.TrimRight(p.lines[i], " \t\r")
+		t := strings.TrimLeft(l, " ")
+		if t == "" {
+			blank++
+			continue
+		}
+		if len(l)-len(t) <= parent || t[0] == '#' {
+			break
+		}
+		if blank > 0 {
+			b.WriteString(strings.Repeat("\n", blank))
+		} else {
+			b.WriteByte(' ')
+		}
+		b.WriteString(t)
+		blank = 0
+		p.pos = i + 1
+	}
+	return b.String()
+}
+
+// blockScalar parses a literal (|) or folded (>) block scalar whose header
+// is text.
+func (p *yamlParser) blockScalar(text string, line, parent int) (*yamlNode, error) {
+	style, header := text[0], text[1:]
+	if i := strings.Index(header, "#"); i >= 0 {
+		header = header[:i]
+	}
+	chomp, explicit := byte(0), 0
+	for _, c := range []byte(strings.TrimSpace(header)) {
+		switch {
+		case (c == '-' || c == '+') && chomp == 0:
+			chomp = c
+		case c >= '1' && c <= '9' && explicit == 0:
+			explicit = int(c - '0')
+		default:
+			return nil, &yamlError{line, "invalid block scalar header"}
+		}
+	}
+
+	// Collect the block's lines: those indented by more than parent, and
+	// blank lines between them.
+	ind := -1
+	if explicit > 0 {
+		ind = max(parent, 0) + explicit
+	}
+	var lines []string
+	for ; p.pos < len(p.lines); p.pos++ {
+		l := strings.TrimRight(p.lines[p.pos], "\r")
+		t := strings.TrimLeft(l, " ")
+		n := len(l) - len(t)
+		if strings.TrimSpace(t) == "" {
+			lines = append(lines, "")
+			continue
+		}
+		if ind < 0 {
+			if n <= parent {
+				break
+			}
+			ind = n
+		}
+		if n < ind {
+			break
+		}
+		lines = append(lines, l[ind:])
+	}
+	trailing := 0
+	for trailing < len(lines) && lines[len(lines)-1-trailing] == "" {
+		trailing++
+	}
+	lines = lines[:len(lines)-trailing]
+
+	var b strings.Builder
+	moreIndented := func(s string) bool { return s != "" && (s[0] == ' ' || s[0] == '\t') }
+	lastText := ""
+	for i, l := range lines {
+		if i > 0 {
+			prev := lines[i-1]
+			switch {
+			case style == '|' || l == "":
+				b.WriteByte('\n')
+			case prev == "":
+				if moreIndented(l) || moreIndented(lastText) {
+					b.WriteByte('\n')
+				}
+			case moreIndented(l) || moreIndented(prev):
+				b.WriteByte('\n')
+			default:
+				b.WriteByte(' ')
+			}
+		}
+		b.WriteString(l)
+		if l != "" {
+			lastText = l
+		}
+	}
+	s := b.String()
+	switch {
+	case len(lines) == 0:
+		if chomp == '+' {
+			s = strings.Repeat("\n", trailing)
+		}
+	case chomp == '+':
+		s += strings.Repeat("\n", trailing+1)
+	case chomp == 0:
+		s += "\n"
+	}
+	return &yamlNode{value: s, line: line}, nil
+}
+
+// unquote reads the single- or double-quoted string at the start of s and
+// returns it with the text after the closing quote.
+func unquote(s string) (string, string, error) {
+	q := s[0]
+	var b strings.Builder
+	for i := 1; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == q && q == '\'' && i+1 < len(s) && s[i+1] == '\'':
+			b.WriteByte('\'')
+			i++
+		case c == q:
+			return b.String(), strings.TrimSpace(s[i+1:]), nil
+		case c == '\\' && q == '"':
+			if i+1 == len(s) {
+				return "", "", fmt.Errorf("unterminated escape sequence")
+			}
+			i++
+			switch e := s[i]; e {
+			case 'n':
+				b.WriteByte('\n')
+			case 't':
+				b.WriteByte('\t')
+			case 'r':
+				b.WriteByte('\r')
+			case '0':
+				b.WriteByte(0)
+			case ' ', '/', '\\', '"':
+				b.WriteByte(e)
+			case 'x', 'u', 'U':
+				size := map[byte]int{'x': 2, 'u': 4, 'U': 8}[e]
+				if i+size >= len(s) {
+					return "", "", fmt.Errorf("invalid escape sequence")
+				}
+				r, err := strconv.ParseUint(s[i+1:i+1+size], 16, 32)
+				if err != nil {
+					return "", "", fmt.Errorf("invalid escape sequence")
+				}
+				b.WriteRune(rune(r))
+				i += size
+			default:
+				return "", "", fmt.Errorf("unknown escape sequence \\%c", e)
+			}
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return "", "", fmt.Errorf("missing closing quote")
+}
+
+// flowParser parses a flow collection such as [a, b] or {k: v}.
+type flowParser struct {
+	text string
+	pos  int
+	line int
+}
+
+func (f *flowParser) errorf(format string, args ...any) error {
+	return &yamlError{line: f.line, msg: fmt.Sprintf(format, args...)}
+}
+
+func (f *flowParser) skip() {
+	for f.pos < len(f.text) && (f.text[f.pos] == ' ' || f.text[f.pos] == '\t' || f.text[f.pos] == '\n') {
+		f.pos++
+	}
+}
+
+func (f *flowParser) node() (*yamlNode, error) {
+	f.skip()
+	if f.pos == len(f.text) {
+		return nil, f.errorf("unexpected end of flow collection")
+	}
+	switch f.text[f.pos] {
+	case '[':
+		return f.collection(yamlSequence, ']')
+	case '{':
+		return f.collection(yamlMapping, '}')
+	case '"', '\'':
+		s, rest, err := unquote(f.text[f.pos:])
+		if err != nil {
+			return nil, f.errorf("%v", err)
+		}
+		f.pos = len(f.text) - len(rest)
+		return &yamlNode{value: s, line: f.line}, nil
+	}
+	start := f.pos
+	for f.pos < len(f.text) && !strings.ContainsRune(",[]{}", rune(f.text[f.pos])) &&
+		!(f.text[f.pos] == ':' && (f.pos+1 == len(f.text) || strings.ContainsRune(" ,]}", rune(f.text[f.pos+1])))) {
+		f.pos++
+	}
+	return &yamlNode{value: strings.TrimSpace(f.text[start:f.pos]), plain: true, line: f.line}, nil
+}
+
+func (f *flowParser) collection(kind yamlKind, end byte) (*yamlNode, error) {
+	n := &yamlNode{kind: kind, line: f.line}
+	f.pos++
+	for {
+		f.skip()
+		if f.pos == len(f.text) {
+			return nil, f.errorf("missing %q in flow collection", end)
+		}
+		if f.text[f.pos] == end {
+			f.pos++
+			return n, nil
+		}
+		item, err := f.node()
+		if err != nil {
+			return nil, err
+		}
+		f.skip()
+		if kind == yamlMapping {
+			if item.kind != yamlScalar || f.pos == len(f.text) || f.text[f.pos] != ':' {
+				return nil, f.errorf("expected a key and ':' in flow mapping")
+			}
+			f.pos++
+			val, err := f.node()
+			if err != nil {
+				return nil, err
+			}
+			n.content = append(n.content, item, val)
+		} else {
+			if f.pos < len(f.text) && f.text[f.pos] == ':' {
+				return nil, f.errorf("mappings in flow sequences are not supported")
+			}
+			n.content = append(n.content, item)
+		}
+		f.skip()
+		if f.pos < len(f.text) && f.text[f.pos] == ',' {
+			f.pos++
+		} else if f.pos < len(f.text) && f.text[f.pos] != end {
+			return nil, f.errorf("expected ',' or %q in flow collection", end)
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package instill
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestParseYAML(t *testing.T) {
+	for _, tt := range []struct {
+		name  string
+		input string
+		want  any
+	}{
+		{"scalars", "a: text\nb: 'it''s'\nc: \"tab\\t\\u00e9\"\nd: 12\ne: 1.5\nf: true\ng: ~\nh:\n", map[string]any{
+			"a": "text", "b": "it's", "c": "tab\té", "d": 12, "e": 1.5, "f": true, "g": nil, "h": nil,
+		}},
+		{"comments", "# top\na: x # trailing\nb: \"y # kept\"\n", map[string]any{"a": "x", "b": "y # kept"}},
+		{"plain continuation", "a: one\n  two\n\n  three\nb: x\n", map[string]any{"a": "one two\nthree", "b": "x"}},
+		{"urls", "a: https://example.com/x\n", map[string]any{"a": "https://example.com/x"}},
+		{"nested mappings", "a:\n  b:\n    c: d\n  e: f\n", map[string]any{"a": map[string]any{"b": map[string]any{"c": "d"}, "e": "f"}}},
+		{"sequences", "a:\n  - x\n  - y: 1\n    z: 2\nb:\n- w\n", map[string]any{
+			"a": []any{"x", map[string]any{"y": 1, "z": 2}}, "b": []any{"w"},
+		}},
+		{"flow", "a: [x, 'y, z', [1]]\nb: {k: v, n: }\nc: [\n  p, q]\n", map[string]any{
+			"a": []any{"x", "y, z", []any{1}}, "b": map[string]any{"k": "v", "n": nil}, "c": []any{"p", "q"},
+		}},
+		{"literal", "a: |\n  one\n    two\n\n  three\n\nb: |-\n  x\nc: |+\n  y\n\n", map[string]any{
+			"a": "one\n  two\n\nthree\n", "b": "x", "c": "y\n\n",
+		}},
+		{"folded", "a: >\n  one\n  two\n\n  three\n    indented\n  four\n", map[string]any{
+			"a": "one two\nthree\n  indented\nfour\n",
+		}},
+		{"quoted keys", "\"a: b\": 1\n'c': 2\n", map[string]any{"a: b": 1, "c": 2}},
+		{"empty", "", nil},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			n, err := parseYAML(tt.input, 1)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := n.decode(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseYAMLErrors(t *testing.T) {
+	for _, tt := range []struct {
+		input string
+		line  int
+	}{
+		{"a: b: c\n", 1},
+		{"a: x\nb: \"open\n", 2},
+		{"a: x\n  b: y\n", 1},
+		{"a:\n  b: 1\n   c: 2\n", 2},
+		{"a: x\na: y\n", 2},
+		{"a: &anchor x\n", 1},
+		{"a: [x, y\n", 1},
+		{"a: x\n\tb: y\n", 2},
+		{"- a\nb: c\n", 2},
+	} {
+		_, err := parseYAML(tt.input, 1)
+		var ye *yamlError
+		if !errors.As(err, &ye) || ye.line != tt.line {
+			t.Errorf("parseYAML(%q) = %v, want an error on line %d", tt.input, err, tt.line)
+		}
+	}
+}